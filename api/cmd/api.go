@@ -11,8 +11,17 @@ import (
 	"time"
 
 	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/handlers"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/models"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/batchsvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/cachesvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/imagesvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/linksvc"
 	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/opengraphsvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/botdetect"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/database"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
 	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/oembed"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
@@ -29,7 +38,17 @@ func RootCmd() *cobra.Command {
 		Path:                "/v1",
 		Port:                port,
 		ShutdownGracePeriod: 5 * time.Second,
+		HumanMode:           handlers.HumanModeRedirect,
 	}
+	fetcherOpts := fetcher.DefaultOptions()
+	var oembedProviders string
+	openGraphOpts := opengraphsvc.DefaultOptions()
+	cacheOpts := cachesvc.DefaultOptions()
+	imageOpts := imagesvc.DefaultOptions()
+	batchOpts := batchsvc.DefaultOptions()
+	linkOpts := linksvc.DefaultOptions()
+	databaseOpts := &database.Options{Driver: "sqlite"}
+	botOpts := botdetect.DefaultOptions()
 	deps := &handlers.Dependencies{
 		Logger: logger.GetInstance(),
 	}
@@ -39,10 +58,44 @@ func RootCmd() *cobra.Command {
 		Short: "serves the tenant REST API",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, cancel := context.WithCancel(context.Background())
-			// gormDB, _ := database.Connection()
-			// deps.GormDB = gormDB
-			openGraphSvc := opengraphsvc.Handler(deps.Logger)
-			deps.Services.OpenGraphSvc = openGraphSvc
+			deps.BotDetector = botdetect.New(botOpts)
+			var store *cachesvc.Store
+			if databaseOpts.Enabled() {
+				gormDB, err := database.Connection(databaseOpts)
+				if err != nil {
+					return Cancel(err, cancel)
+				}
+				if err := models.AutoMigrate(gormDB); err != nil {
+					return Cancel(errors.Wrap(err, "migrating database"), cancel)
+				}
+				deps.GormDB = gormDB
+				store = cachesvc.NewStore(gormDB, cacheOpts.StaleTTL)
+			}
+			pageFetcher, err := fetcher.New(fetcherOpts, deps.Logger)
+			if err != nil {
+				return Cancel(err, cancel)
+			}
+			deps.Fetcher = pageFetcher
+			oembedRegistry := oembed.DefaultRegistry()
+			if oembedProviders != "" {
+				oembedRegistry, err = oembed.LoadRegistry(oembedProviders)
+				if err != nil {
+					return Cancel(err, cancel)
+				}
+			}
+			openGraphSvc, err := opengraphsvc.Handler(deps.Logger, pageFetcher, oembedRegistry, openGraphOpts)
+			if err != nil {
+				return Cancel(err, cancel)
+			}
+			deps.Services.OpenGraphSvc = cachesvc.Handler(deps.Logger, openGraphSvc, store, cacheOpts)
+			deps.Services.BatchSvc = batchsvc.Handler(deps.Logger, deps.Services.OpenGraphSvc, batchOpts)
+			deps.Services.ImageSvc, err = imagesvc.Handler(deps.Logger, pageFetcher, imageOpts)
+			if err != nil {
+				return Cancel(err, cancel)
+			}
+			if deps.GormDB != nil {
+				deps.Services.LinkSvc = linksvc.Handler(deps.Logger, deps.GormDB, deps.Services.OpenGraphSvc, linkOpts)
+			}
 
 			service, serviceErr := handlers.NewService(ctx, opts, deps)
 			if serviceErr != nil {
@@ -62,6 +115,17 @@ func RootCmd() *cobra.Command {
 		},
 	}
 
+	c.Flags().AddFlagSet(opts.GetFlagSet())
+	c.Flags().AddFlagSet(fetcherOpts.GetFlagSet())
+	c.Flags().AddFlagSet(openGraphOpts.GetFlagSet())
+	c.Flags().AddFlagSet(cacheOpts.GetFlagSet())
+	c.Flags().AddFlagSet(imageOpts.GetFlagSet())
+	c.Flags().AddFlagSet(batchOpts.GetFlagSet())
+	c.Flags().AddFlagSet(linkOpts.GetFlagSet())
+	c.Flags().AddFlagSet(databaseOpts.GetFlagSet())
+	c.Flags().AddFlagSet(botOpts.GetFlagSet())
+	c.Flags().StringVar(&oembedProviders, "oembed-providers", oembedProviders, "providers.json file with extra oEmbed providers")
+
 	return c
 }
 
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/batchsvc"
+	"github.com/labstack/echo/v4"
+)
+
+const mimeApplicationNDJSON = "application/x-ndjson"
+
+type BatchService interface {
+	GetMetadataBatch(ctx context.Context, items []routes.MetadataBatchItem, emit func(batchsvc.Result)) error
+}
+
+// GetMetadataBatch - Get metadata of several URLs
+// (POST /metadata/batch)
+func (svc *Service) GetMetadataBatch(c echo.Context, params routes.GetMetadataBatchParams) error {
+	var request routes.GetMetadataBatchJSONRequestBody
+	if err := c.Bind(&request); err != nil {
+		return svc.sendError(c, bindError(err))
+	}
+	ctx := c.Request().Context()
+
+	if params.Stream != nil && *params.Stream {
+		// the status can only be sent once, so it goes out with the first line
+		res := c.Response()
+		encoder := json.NewEncoder(res)
+		encoder.SetEscapeHTML(false)
+		started := false
+		err := svc.Services.BatchSvc.GetMetadataBatch(ctx, request.Items, func(r batchsvc.Result) {
+			if !started {
+				res.Header().Set(echo.HeaderContentType, mimeApplicationNDJSON)
+				res.WriteHeader(http.StatusOK)
+				started = true
+			}
+			if err := encoder.Encode(svc.batchResult(c, r)); err != nil {
+				svc.logger.Debugw("writing batch result failed", "error", err)
+				return
+			}
+			res.Flush()
+		})
+		if err != nil {
+			return svc.sendError(c, err)
+		}
+		return nil
+	}
+
+	response := routes.MetadataBatchResponse{
+		Results: make([]routes.MetadataBatchResult, len(request.Items)),
+	}
+	err := svc.Services.BatchSvc.GetMetadataBatch(ctx, request.Items, func(r batchsvc.Result) {
+		response.Results[r.Index] = svc.batchResult(c, r)
+	})
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// batchResult converts the result of one item like GetMetadata would.
+func (svc *Service) batchResult(c echo.Context, r batchsvc.Result) routes.MetadataBatchResult {
+	result := routes.MetadataBatchResult{
+		Index: r.Index,
+		Url:   r.Item.Url,
+	}
+	if r.Err != nil {
+		_, body := svc.errorResponse(c, r.Err)
+		result.Error = &body
+		return result
+	}
+	metadata := r.Metadata
+	if r.Item.ProxyImages != nil && *r.Item.ProxyImages {
+		metadata = svc.proxyImages(c, metadata)
+	}
+	result.Metadata = &metadata
+	return result
+}
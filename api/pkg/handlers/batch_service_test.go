@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/batchsvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// titleUpstream answers with the URL as the title, later items first.
+type titleUpstream struct{}
+
+func (titleUpstream) GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error) {
+	if strings.HasSuffix(params.Url, "/0") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return routes.Metadata{Title: params.Url}, nil
+}
+
+func postBatch(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	svc := &Service{
+		logger: logger.GetInstance(),
+		Services: Services{
+			BatchSvc: batchsvc.Handler(logger.GetInstance(), titleUpstream{}, batchsvc.DefaultOptions()),
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/metadata/batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := svc.GetMetadataBatch(echo.New().NewContext(req, rec), routes.GetMetadataBatchParams{}); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestGetMetadataBatchInputOrder(t *testing.T) {
+	rec := postBatch(t, `{"items":[{"url":"https://example.com/0"},{"url":"https://example.com/1"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var response routes.MetadataBatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(response.Results))
+	}
+	for i, r := range response.Results {
+		want := fmt.Sprintf("https://example.com/%d", i)
+		if r.Index != i || r.Url != want || r.Metadata == nil || r.Metadata.Title != want {
+			t.Errorf("result %d = %+v, want the metadata of %s", i, r, want)
+		}
+	}
+}
+
+func TestGetMetadataBatchInvalidBody(t *testing.T) {
+	for _, body := range []string{`{"items":`, `{"items":"x"}`} {
+		rec := postBatch(t, body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, rec.Code)
+		}
+		var response routes.Error
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: %v in %s", body, err, rec.Body)
+		}
+		if response.Code != "invalid_input" || response.Message == "" {
+			t.Errorf("%s: error = %+v, want invalid_input", body, response)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/batchsvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/imagesvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/linksvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/opengraphsvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const errorCodeInternal = "internal"
+
+// errInvalidBody is returned for request bodies that cannot be decoded.
+var errInvalidBody = errors.New("invalid request body")
+
+// statusClientClosedRequest is reported for requests whose client went away
+// before the fetch finished. Nobody reads it, but it keeps them apart from
+// server errors in the logs.
+const statusClientClosedRequest = 499
+
+// errorStatuses maps fetch failures to the status returned to clients.
+// Conditional fetches are answered by the cache, so not_modified only
+// reaches a client as an upstream answer it cannot use.
+var errorStatuses = map[fetcher.Kind]int{
+	fetcher.KindInvalidURL:       http.StatusBadRequest,
+	fetcher.KindBlocked:          http.StatusForbidden,
+	fetcher.KindDisallowed:       http.StatusForbidden,
+	fetcher.KindTooLarge:         http.StatusRequestEntityTooLarge,
+	fetcher.KindNotHTML:          http.StatusUnsupportedMediaType,
+	fetcher.KindNotImage:         http.StatusUnsupportedMediaType,
+	fetcher.KindUpstreamStatus:   http.StatusFailedDependency,
+	fetcher.KindUnreachable:      http.StatusBadGateway,
+	fetcher.KindTooManyRedirects: http.StatusBadGateway,
+	fetcher.KindNotModified:      http.StatusBadGateway,
+	fetcher.KindTimeout:          http.StatusGatewayTimeout,
+	fetcher.KindCanceled:         statusClientClosedRequest,
+	fetcher.KindThrottled:        http.StatusTooManyRequests,
+}
+
+// serviceErrors maps sentinel errors of the services to their code and
+// status.
+var serviceErrors = []struct {
+	err    error
+	code   string
+	status int
+}{
+	{linksvc.ErrInvalidInput, "invalid_input", http.StatusBadRequest},
+	{batchsvc.ErrInvalidInput, "invalid_input", http.StatusBadRequest},
+	{fetcher.ErrUnknownProfile, "invalid_input", http.StatusBadRequest},
+	{opengraphsvc.ErrUnknownTemplate, "invalid_input", http.StatusBadRequest},
+	{opengraphsvc.ErrInvalidParameter, "invalid_input", http.StatusBadRequest},
+	{imagesvc.ErrInvalidParameter, "invalid_input", http.StatusBadRequest},
+	{errInvalidBody, "invalid_input", http.StatusBadRequest},
+	{linksvc.ErrNotFound, "not_found", http.StatusNotFound},
+	{linksvc.ErrSlugTaken, "conflict", http.StatusConflict},
+	{linksvc.ErrUnauthorized, "unauthorized", http.StatusUnauthorized},
+	{linksvc.ErrForbidden, "forbidden", http.StatusForbidden},
+	{errLinksUnavailable, "unavailable", http.StatusServiceUnavailable},
+}
+
+// bindError wraps a failure of echo.Context.Bind in errInvalidBody, keeping
+// the message of echo's error without its status.
+func bindError(err error) error {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return errors.Wrapf(errInvalidBody, "%v", httpErr.Message)
+	}
+	return errors.Wrap(errInvalidBody, err.Error())
+}
+
+// sendError writes err as a routes.Error, see errorResponse.
+func (svc *Service) sendError(c echo.Context, err error) error {
+	status, body := svc.errorResponse(c, err)
+	return c.JSON(status, body)
+}
+
+// errorResponse converts err into a status and a routes.Error. Fetch
+// failures keep their kind as the error code, known service errors use
+// their own code, and anything else is reported as an internal error.
+func (svc *Service) errorResponse(c echo.Context, err error) (int, routes.Error) {
+	for _, known := range serviceErrors {
+		if errors.Is(err, known.err) {
+			return known.status, routes.Error{
+				Code:    known.code,
+				Message: err.Error(),
+			}
+		}
+	}
+
+	fetchErr, ok := fetcher.AsError(err)
+	if !ok {
+		svc.logger.Errorw("request failed", "path", c.Path(), "error", err)
+		return http.StatusInternalServerError, routes.Error{
+			Code:    errorCodeInternal,
+			Message: "internal server error",
+		}
+	}
+
+	status, ok := errorStatuses[fetchErr.Kind]
+	if !ok {
+		status = http.StatusBadGateway
+	}
+	svc.logger.Infow("upstream fetch failed", "path", c.Path(), "kind", fetchErr.Kind, "error", err)
+	body := routes.Error{
+		Code:    string(fetchErr.Kind),
+		Message: fetchErr.Error(),
+	}
+	if fetchErr.StatusCode != 0 {
+		upstreamStatus := fetchErr.StatusCode
+		body.UpstreamStatus = &upstreamStatus
+	}
+	return status, body
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/linksvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/services/opengraphsvc"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+func TestErrorResponse(t *testing.T) {
+	fetchErr := func(kind fetcher.Kind) error {
+		return errors.Wrap(&fetcher.Error{Kind: kind, URL: "https://example.com/", Err: errors.New("failed")}, "loading page")
+	}
+	tests := []struct {
+		err            error
+		status         int
+		code           string
+		upstreamStatus int
+	}{
+		{fetchErr(fetcher.KindInvalidURL), http.StatusBadRequest, "invalid_url", 0},
+		{fetchErr(fetcher.KindBlocked), http.StatusForbidden, "blocked", 0},
+		{fetchErr(fetcher.KindDisallowed), http.StatusForbidden, "disallowed", 0},
+		{fetchErr(fetcher.KindTooLarge), http.StatusRequestEntityTooLarge, "too_large", 0},
+		{fetchErr(fetcher.KindNotHTML), http.StatusUnsupportedMediaType, "not_html", 0},
+		{fetchErr(fetcher.KindNotImage), http.StatusUnsupportedMediaType, "not_image", 0},
+		{fetchErr(fetcher.KindUnreachable), http.StatusBadGateway, "unreachable", 0},
+		{fetchErr(fetcher.KindTooManyRedirects), http.StatusBadGateway, "too_many_redirects", 0},
+		{fetchErr(fetcher.KindNotModified), http.StatusBadGateway, "not_modified", 0},
+		{fetchErr(fetcher.KindTimeout), http.StatusGatewayTimeout, "timeout", 0},
+		{fetchErr(fetcher.KindCanceled), statusClientClosedRequest, "canceled", 0},
+		{fetchErr(fetcher.KindThrottled), http.StatusTooManyRequests, "throttled", 0},
+		{&fetcher.Error{Kind: fetcher.KindUpstreamStatus, URL: "https://example.com/", StatusCode: 404}, http.StatusFailedDependency, "upstream_status", 404},
+		// kinds added later still report their code
+		{fetchErr("new_kind"), http.StatusBadGateway, "new_kind", 0},
+		{errors.Wrap(linksvc.ErrNotFound, `slug "x"`), http.StatusNotFound, "not_found", 0},
+		{errors.Wrap(linksvc.ErrUnauthorized, `slug "x"`), http.StatusUnauthorized, "unauthorized", 0},
+		{errors.Wrap(linksvc.ErrForbidden, `slug "x"`), http.StatusForbidden, "forbidden", 0},
+		{errors.Wrap(opengraphsvc.ErrInvalidParameter, "size"), http.StatusBadRequest, "invalid_input", 0},
+		{bindError(errors.New("unexpected EOF")), http.StatusBadRequest, "invalid_input", 0},
+		{errors.New("database down"), http.StatusInternalServerError, errorCodeInternal, 0},
+	}
+	svc := &Service{logger: logger.GetInstance()}
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/metadata", nil), httptest.NewRecorder())
+	for _, tt := range tests {
+		status, body := svc.errorResponse(c, tt.err)
+		if status != tt.status || body.Code != tt.code {
+			t.Errorf("%v: %d %s, want %d %s", tt.err, status, body.Code, tt.status, tt.code)
+		}
+		var upstreamStatus int
+		if body.UpstreamStatus != nil {
+			upstreamStatus = *body.UpstreamStatus
+		}
+		if upstreamStatus != tt.upstreamStatus {
+			t.Errorf("%v: upstream status %d, want %d", tt.err, upstreamStatus, tt.upstreamStatus)
+		}
+	}
+	if _, body := svc.errorResponse(c, errors.New("secret dsn")); body.Message != "internal server error" {
+		t.Errorf("internal error message %q leaked", body.Message)
+	}
+}
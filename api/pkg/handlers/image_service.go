@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/labstack/echo/v4"
+)
+
+type ImageService interface {
+	GetImage(ctx context.Context, params routes.GetImageParams) (string, []byte, error)
+}
+
+// GetImage - Resize and re-encode a remote image
+// (GET /image)
+func (svc *Service) GetImage(c echo.Context, params routes.GetImageParams) error {
+
+	ctx := cache.WithStatus(c.Request().Context())
+	contentType, img, err := svc.Services.ImageSvc.GetImage(ctx, params)
+	setCacheHeader(ctx, c)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=86400")
+	return c.Blob(http.StatusOK, contentType, img)
+}
+
+// proxyImages points the image URLs of metadata at the /image proxy. The
+// metadata may be shared with the cache, so everything rewritten is copied.
+func (svc *Service) proxyImages(c echo.Context, metadata routes.Metadata) routes.Metadata {
+	base := c.Scheme() + "://" + c.Request().Host + svc.opts.Path + "/image?url="
+	proxy := func(u string) string {
+		if !isWebURL(u) {
+			return u
+		}
+		return base + url.QueryEscape(u)
+	}
+	proxyPtr := func(u *string) *string {
+		if u == nil {
+			return nil
+		}
+		proxied := proxy(*u)
+		return &proxied
+	}
+
+	metadata.Image = proxy(metadata.Image)
+	if metadata.Images != nil {
+		images := make([]routes.OpenGraphImage, len(*metadata.Images))
+		for i, image := range *metadata.Images {
+			image.Url = proxy(image.Url)
+			image.SecureUrl = proxyPtr(image.SecureUrl)
+			images[i] = image
+		}
+		metadata.Images = &images
+	}
+	if metadata.Oembed != nil {
+		oembed := *metadata.Oembed
+		oembed.ThumbnailUrl = proxyPtr(oembed.ThumbnailUrl)
+		if oembed.Type == "photo" {
+			oembed.Url = proxyPtr(oembed.Url)
+		}
+		metadata.Oembed = &oembed
+	}
+	if metadata.StructuredData != nil {
+		data := *metadata.StructuredData
+		data.Image = proxyPtr(data.Image)
+		metadata.StructuredData = &data
+	}
+	return metadata
+}
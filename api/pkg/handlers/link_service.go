@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type LinkService interface {
+	CreateLink(ctx context.Context, input routes.LinkInput) (routes.Link, error)
+	ListLinks(ctx context.Context, token string, params routes.ListLinksParams) (routes.LinkList, error)
+	GetLink(ctx context.Context, slug string) (routes.Link, error)
+	UpdateLink(ctx context.Context, slug, token string, update routes.LinkUpdate) (routes.Link, error)
+	DeleteLink(ctx context.Context, slug, token string) error
+	ServeLink(ctx context.Context, slug string) (string, error)
+}
+
+// errLinksUnavailable is returned by the link endpoints when no database
+// has been configured.
+var errLinksUnavailable = errors.New("short links need a database, see --database-dsn")
+
+// CreateLink - Create a short link
+// (POST /links)
+func (svc *Service) CreateLink(c echo.Context) error {
+	if svc.Services.LinkSvc == nil {
+		return svc.sendError(c, errLinksUnavailable)
+	}
+	var input routes.CreateLinkJSONRequestBody
+	if err := c.Bind(&input); err != nil {
+		return svc.sendError(c, bindError(err))
+	}
+
+	link, err := svc.Services.LinkSvc.CreateLink(c.Request().Context(), input)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, withShortURL(c, link))
+}
+
+// ListLinks - List short links
+// (GET /links)
+func (svc *Service) ListLinks(c echo.Context, params routes.ListLinksParams) error {
+	if svc.Services.LinkSvc == nil {
+		return svc.sendError(c, errLinksUnavailable)
+	}
+
+	list, err := svc.Services.LinkSvc.ListLinks(c.Request().Context(), bearerToken(c), params)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	for i := range list.Links {
+		list.Links[i] = withShortURL(c, list.Links[i])
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GetLink - Get a short link
+// (GET /links/{slug})
+func (svc *Service) GetLink(c echo.Context, slug string) error {
+	if svc.Services.LinkSvc == nil {
+		return svc.sendError(c, errLinksUnavailable)
+	}
+
+	link, err := svc.Services.LinkSvc.GetLink(c.Request().Context(), slug)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, withShortURL(c, link))
+}
+
+// UpdateLink - Update a short link
+// (PATCH /links/{slug})
+func (svc *Service) UpdateLink(c echo.Context, slug string) error {
+	if svc.Services.LinkSvc == nil {
+		return svc.sendError(c, errLinksUnavailable)
+	}
+	var update routes.UpdateLinkJSONRequestBody
+	if err := c.Bind(&update); err != nil {
+		return svc.sendError(c, bindError(err))
+	}
+
+	link, err := svc.Services.LinkSvc.UpdateLink(c.Request().Context(), slug, bearerToken(c), update)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, withShortURL(c, link))
+}
+
+// DeleteLink - Delete a short link
+// (DELETE /links/{slug})
+func (svc *Service) DeleteLink(c echo.Context, slug string) error {
+	if svc.Services.LinkSvc == nil {
+		return svc.sendError(c, errLinksUnavailable)
+	}
+
+	if err := svc.Services.LinkSvc.DeleteLink(c.Request().Context(), slug, bearerToken(c)); err != nil {
+		return svc.sendError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ServeLink - Serve a short link
+// (GET /l/{slug})
+func (svc *Service) ServeLink(c echo.Context, slug string, params routes.ServeLinkParams) error {
+	if svc.Services.LinkSvc == nil {
+		return svc.sendError(c, errLinksUnavailable)
+	}
+	redirect, err := svc.redirectHuman(c, params.Mode)
+	if err != nil {
+		return err
+	}
+	if redirect {
+		link, err := svc.Services.LinkSvc.GetLink(c.Request().Context(), slug)
+		if err != nil {
+			return svc.sendError(c, err)
+		}
+		return c.Redirect(http.StatusFound, link.Url)
+	}
+
+	ctx := cache.WithStatus(c.Request().Context())
+	html, err := svc.Services.LinkSvc.ServeLink(ctx, slug)
+	setCacheHeader(ctx, c)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	return c.HTML(http.StatusOK, html)
+}
+
+// bearerToken returns the token of the Authorization header, if any.
+func bearerToken(c echo.Context) string {
+	scheme, token, ok := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// withShortURL sets the absolute URL of the root short link route.
+func withShortURL(c echo.Context, link routes.Link) routes.Link {
+	link.ShortUrl = c.Scheme() + "://" + c.Request().Host + "/l/" + link.Slug
+	return link
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/labstack/echo/v4"
+)
+
+// GetMetrics - Counters of the fetcher
+// (GET /metrics)
+func (svc *Service) GetMetrics(c echo.Context) error {
+	response := routes.FetcherMetrics{Errors: map[string]int64{}}
+	if svc.fetcher == nil {
+		return c.JSON(http.StatusOK, response)
+	}
+
+	metrics := svc.fetcher.Metrics()
+	response.Requests = metrics.Requests
+	for kind, n := range metrics.Errors {
+		response.Errors[string(kind)] = n
+	}
+	response.Robots.CacheHits = metrics.Robots.CacheHits
+	response.Robots.CacheMisses = metrics.Robots.CacheMisses
+	response.Robots.CacheEntries = metrics.Robots.CacheEntries
+	response.Robots.Disallowed = metrics.Robots.Disallowed
+	return c.JSON(http.StatusOK, response)
+}
@@ -2,25 +2,37 @@ package handlers
 
 import (
 	"context"
+	"mime"
 	"net/http"
 
 	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
 	"github.com/labstack/echo/v4"
 )
 
 type OpenGraphService interface {
 	OpenGraphEditor(ctx context.Context, params routes.OpenGraphParams) (string, error)
 	GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error)
+	GetFavicon(ctx context.Context, params routes.GetFaviconParams) (string, []byte, error)
+	GetThumbnail(ctx context.Context, params routes.GetThumbnailParams) (string, []byte, error)
 }
 
 // OpenGraph - Data
 // (GET /opengraph)
 func (svc *Service) OpenGraph(c echo.Context, params routes.OpenGraphParams) error {
+	redirect, err := svc.redirectHuman(c, params.Mode)
+	if err != nil {
+		return err
+	}
+	if redirect && isWebURL(params.Url) {
+		return c.Redirect(http.StatusFound, params.Url)
+	}
 
-	html, err := svc.Services.OpenGraphSvc.OpenGraphEditor(c.Request().Context(), params)
+	ctx := cache.WithStatus(c.Request().Context())
+	html, err := svc.Services.OpenGraphSvc.OpenGraphEditor(ctx, params)
+	setCacheHeader(ctx, c)
 	if err != nil {
-		svc.logger.Error("Failed to get OpenGraph data:", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get OpenGraph data")
+		return svc.sendError(c, err)
 	}
 
 	return c.HTML(http.StatusOK, html)
@@ -28,11 +40,69 @@ func (svc *Service) OpenGraph(c echo.Context, params routes.OpenGraphParams) err
 
 func (svc *Service) GetMetadata(c echo.Context, params routes.GetMetadataParams) error {
 
-	metadata, err := svc.Services.OpenGraphSvc.GetMetadata(c.Request().Context(), params)
+	ctx := cache.WithStatus(c.Request().Context())
+	metadata, err := svc.Services.OpenGraphSvc.GetMetadata(ctx, params)
+	setCacheHeader(ctx, c)
 	if err != nil {
-		svc.logger.Error("Failed to get OpenGraph data:", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get OpenGraph data")
+		return svc.sendError(c, err)
 	}
 
+	if params.ProxyImages != nil && *params.ProxyImages {
+		metadata = svc.proxyImages(c, metadata)
+	}
 	return c.JSON(http.StatusOK, metadata)
 }
+
+// GetFavicon - Icon of a site
+// (GET /favicon)
+func (svc *Service) GetFavicon(c echo.Context, params routes.GetFaviconParams) error {
+
+	contentType, icon, err := svc.Services.OpenGraphSvc.GetFavicon(c.Request().Context(), params)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	// the icon is third party content served from our origin, and an SVG
+	// may carry scripts
+	header := c.Response().Header()
+	header.Set("Content-Security-Policy", "sandbox")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": "favicon" + extension(contentType)}))
+	return c.Blob(http.StatusOK, contentType, icon)
+}
+
+// GetThumbnail - Render a social card of a URL
+// (GET /thumbnail)
+func (svc *Service) GetThumbnail(c echo.Context, params routes.GetThumbnailParams) error {
+
+	contentType, card, err := svc.Services.OpenGraphSvc.GetThumbnail(c.Request().Context(), params)
+	if err != nil {
+		return svc.sendError(c, err)
+	}
+
+	return c.Blob(http.StatusOK, contentType, card)
+}
+
+// extension returns the usual file extension of contentType, if known.
+func extension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "image/x-icon", "image/vnd.microsoft.icon":
+		return ".ico"
+	case "image/svg+xml":
+		return ".svg"
+	case "image/jpeg":
+		return ".jpg"
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// setCacheHeader reports the cache status recorded in ctx, if any.
+func setCacheHeader(ctx context.Context, c echo.Context) {
+	if status := cache.StatusFromContext(ctx); status != "" {
+		c.Response().Header().Set("X-Cache", string(status))
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/labstack/echo/v4"
+)
+
+// How previews answer people, see Options.HumanMode.
+const (
+	HumanModeRedirect     = "redirect"
+	HumanModeInterstitial = "interstitial"
+)
+
+// redirectHuman reports whether a preview request should be answered with
+// a redirect to the target rather than the preview page. mode overrides the
+// User-Agent based detection.
+func (svc *Service) redirectHuman(c echo.Context, mode *routes.PreviewMode) (bool, error) {
+	if mode != nil {
+		switch *mode {
+		case routes.Bot:
+			return false, nil
+		case routes.Human:
+			return svc.opts.HumanMode == HumanModeRedirect, nil
+		default:
+			return false, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter mode: unknown mode %q", *mode))
+		}
+	}
+	// the response depends on who asks, shared caches must keep both
+	c.Response().Header().Add(echo.HeaderVary, "User-Agent")
+	if svc.bots.IsBot(c.Request().UserAgent()) {
+		return false, nil
+	}
+	return svc.opts.HumanMode == HumanModeRedirect, nil
+}
+
+// isWebURL reports whether people may be redirected to raw.
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -7,9 +7,12 @@ import (
 	"time"
 
 	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/botdetect"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
 	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	"gorm.io/gorm"
 )
@@ -24,10 +27,12 @@ type EchoServer interface {
 }
 
 type Service struct {
-	ctx    context.Context
-	opts   *Options
-	logger logger.Logger
-	server EchoServer
+	ctx     context.Context
+	opts    *Options
+	logger  logger.Logger
+	server  EchoServer
+	bots    *botdetect.Detector
+	fetcher *fetcher.Fetcher
 
 	Services Services
 }
@@ -38,6 +43,8 @@ type Dependencies struct {
 	EchoServer    EchoServer
 	MessageBroker MessageBroker
 	GormDB        *gorm.DB
+	BotDetector   *botdetect.Detector
+	Fetcher       *fetcher.Fetcher
 	Services      Services
 }
 
@@ -53,10 +60,16 @@ type Options struct {
 	Path                string
 	Port                int
 	ShutdownGracePeriod time.Duration
+	// HumanMode is how previews answer people, HumanModeRedirect or
+	// HumanModeInterstitial. Crawlers always get the preview page.
+	HumanMode string
 }
 
 type Services struct {
 	OpenGraphSvc OpenGraphService
+	LinkSvc      LinkService
+	ImageSvc     ImageService
+	BatchSvc     BatchService
 }
 
 // GetFlagSet returns flag set for Options
@@ -65,6 +78,7 @@ func (o *Options) GetFlagSet() *pflag.FlagSet {
 	flags.StringVar(&o.Path, "path", o.Path, "path to serve API on")
 	flags.IntVar(&o.Port, "port", o.Port, "port to serve API on")
 	flags.DurationVar(&o.ShutdownGracePeriod, "shutdown-grace-period", o.ShutdownGracePeriod, "shutdown grace period")
+	flags.StringVar(&o.HumanMode, "human-mode", o.HumanMode, "how previews answer people: redirect or interstitial")
 	return flags
 }
 
@@ -75,8 +89,16 @@ func NewService(ctx context.Context, opts *Options, deps *Dependencies) (*Servic
 		opts:     opts,
 		logger:   deps.Logger,
 		server:   deps.EchoServer,
+		bots:     deps.BotDetector,
+		fetcher:  deps.Fetcher,
 		Services: deps.Services,
 	}
+	if svc.bots == nil {
+		svc.bots = botdetect.New(botdetect.DefaultOptions())
+	}
+	if opts.HumanMode != HumanModeRedirect && opts.HumanMode != HumanModeInterstitial {
+		return nil, errors.Errorf("unknown human mode %q", opts.HumanMode)
+	}
 	svc.server = svc.createServer()
 	return svc, nil
 }
@@ -105,5 +127,8 @@ func (svc *Service) createServer() EchoServer {
 	// server.Use(svc.AuthzMiddleware)
 	apiGroup := server.Group("")
 	routes.RegisterHandlersWithBaseURL(apiGroup, svc, svc.opts.Path)
+	// short links are also served without the API prefix to keep them short
+	wrapper := routes.ServerInterfaceWrapper{Handler: svc}
+	server.GET("/l/:slug", wrapper.ServeLink)
 	return server
 }
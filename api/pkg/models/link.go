@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Link is a short link serving a customised preview of URL.
+type Link struct {
+	ID   uint   `gorm:"primaryKey"`
+	Slug string `gorm:"uniqueIndex;size:64"`
+	URL  string `gorm:"type:text"`
+	// Title, Description and Image override the values of the page when set.
+	Title       *string
+	Description *string
+	Image       *string `gorm:"type:text"`
+	// Template is the preview page template, the default one when nil.
+	Template *string `gorm:"size:64"`
+	// TokenHash is the SHA-256 of the owner token, hex encoded.
+	TokenHash string `gorm:"size:64"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
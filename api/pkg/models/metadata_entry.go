@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MetadataEntry is a persisted metadata lookup, shared by every replica.
+type MetadataEntry struct {
+	// Key is a hash of the cache key, which embeds the page URL and options.
+	Key string `gorm:"primaryKey;size:64"`
+	URL string `gorm:"type:text"`
+	// Data is the JSON encoded routes.Metadata.
+	Data         []byte
+	StatusCode   int
+	ETag         string `gorm:"column:etag"`
+	LastModified string
+	CacheControl string
+	FetchedAt    time.Time
+	// ExpiresAt is when the entry becomes stale and must be revalidated.
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
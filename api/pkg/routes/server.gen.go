@@ -6,23 +6,456 @@ package routes
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime"
 )
 
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for ImageFit.
+const (
+	Contain ImageFit = "contain"
+	Cover   ImageFit = "cover"
+	Crop    ImageFit = "crop"
+)
+
+// Defines values for ImageFormat.
+const (
+	Gif  ImageFormat = "gif"
+	Jpeg ImageFormat = "jpeg"
+	Png  ImageFormat = "png"
+)
+
+// Defines values for PreviewMode.
+const (
+	Bot   PreviewMode = "bot"
+	Human PreviewMode = "human"
+)
+
+// Defines values for ThumbnailLayout.
+const (
+	Hero     ThumbnailLayout = "hero"
+	Standard ThumbnailLayout = "standard"
+	Text     ThumbnailLayout = "text"
+)
+
+// Article defines model for Article.
+type Article struct {
+	Authors        *[]string `json:"authors,omitempty"`
+	ExpirationTime *string   `json:"expirationTime,omitempty"`
+	ModifiedTime   *string   `json:"modifiedTime,omitempty"`
+	PublishedTime  *string   `json:"publishedTime,omitempty"`
+	Section        *string   `json:"section,omitempty"`
+	Tags           *[]string `json:"tags,omitempty"`
+}
+
+// Book defines model for Book.
+type Book struct {
+	Authors     *[]string `json:"authors,omitempty"`
+	Isbn        *string   `json:"isbn,omitempty"`
+	ReleaseDate *string   `json:"releaseDate,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+}
+
+// Breadcrumb defines model for Breadcrumb.
+type Breadcrumb struct {
+	Name string  `json:"name"`
+	Url  *string `json:"url,omitempty"`
+}
+
+// Error defines model for Error.
+type Error struct {
+	// Code Machine readable error code. One of invalid_url (400), blocked (403), disallowed (403),
+	// too_large (413), not_html (415), upstream_status (424), unreachable (502),
+	// too_many_redirects (502), not_modified (502), timeout (504), canceled (499), throttled (429),
+	// not_image (415), invalid_input (400), not_found (404), unauthorized (401), forbidden (403),
+	// conflict (409), unavailable (503) or internal (500).
+	Code string `json:"code"`
+
+	// Message Human readable description of the error.
+	Message string `json:"message"`
+
+	// UpstreamStatus HTTP status returned by the upstream server for upstream_status errors.
+	UpstreamStatus *int `json:"upstreamStatus,omitempty"`
+}
+
+// FetcherMetrics defines model for FetcherMetrics.
+type FetcherMetrics struct {
+	// Errors Failed requests by error code.
+	Errors   map[string]int64 `json:"errors"`
+	Requests int64            `json:"requests"`
+	Robots   struct {
+		CacheEntries int   `json:"cacheEntries"`
+		CacheHits    int64 `json:"cacheHits"`
+		CacheMisses  int64 `json:"cacheMisses"`
+
+		// Disallowed Requests robots.txt disallowed, refused or not depending on the robots mode.
+		Disallowed int64 `json:"disallowed"`
+	} `json:"robots"`
+}
+
+// Icon defines model for Icon.
+type Icon struct {
+	Height *int `json:"height,omitempty"`
+
+	// Rel icon, apple-touch-icon, mask-icon or manifest
+	Rel   string  `json:"rel"`
+	Sizes *string `json:"sizes,omitempty"`
+
+	// Source link or manifest
+	Source string  `json:"source"`
+	Type   *string `json:"type,omitempty"`
+	Url    string  `json:"url"`
+	Width  *int    `json:"width,omitempty"`
+}
+
+// ImageFit cover scales the image to fill the box and crops the overflow, contain scales it to
+// fit within the box and crop cuts the box out of the centre without scaling.
+type ImageFit string
+
+// ImageFormat defines model for ImageFormat.
+type ImageFormat string
+
+// ImageProbe defines model for ImageProbe.
+type ImageProbe struct {
+	// ContentType Media type sniffed from the image bytes.
+	ContentType *string `json:"contentType,omitempty"`
+	Height      *int    `json:"height,omitempty"`
+
+	// Reason Why the image is invalid: an error code such as upstream_status or not_image,
+	// undecodable, too_small, too_large or bad_aspect_ratio.
+	Reason *string `json:"reason,omitempty"`
+
+	// Size Size of the image in bytes, when the server reported it.
+	Size *int64 `json:"size,omitempty"`
+
+	// Source Where the image was found, see Metadata.imageSource.
+	Source *string `json:"source,omitempty"`
+	Url    string  `json:"url"`
+	Valid  bool    `json:"valid"`
+	Width  *int    `json:"width,omitempty"`
+}
+
+// InvalidUrl defines model for InvalidUrl.
+type InvalidUrl struct {
+	// Field The property or link the value was read from, e.g. og:image.
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+	Value  string `json:"value"`
+}
+
+// Link defines model for Link.
+type Link struct {
+	CreatedAt   time.Time `json:"createdAt"`
+	Description *string   `json:"description,omitempty"`
+	Image       *string   `json:"image,omitempty"`
+
+	// ShortUrl Absolute URL serving the preview.
+	ShortUrl string `json:"shortUrl"`
+	Slug     string `json:"slug"`
+
+	// Template Preview page template, default if omitted.
+	Template *string `json:"template,omitempty"`
+	Title    *string `json:"title,omitempty"`
+
+	// Token Owner token of the link, only returned when it is created.
+	Token     *string   `json:"token,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Url The page whose preview is customised.
+	Url string `json:"url"`
+}
+
+// LinkInput defines model for LinkInput.
+type LinkInput struct {
+	Description *string `json:"description,omitempty"`
+	Image       *string `json:"image,omitempty"`
+
+	// Slug Custom slug of 3 to 64 letters, digits, dashes or underscores. Generated if omitted.
+	Slug *string `json:"slug,omitempty"`
+
+	// Template Preview page template, default if omitted.
+	Template *string `json:"template,omitempty"`
+	Title    *string `json:"title,omitempty"`
+	Url      string  `json:"url"`
+}
+
+// LinkList defines model for LinkList.
+type LinkList struct {
+	Links []Link `json:"links"`
+
+	// Total Number of links in total.
+	Total int `json:"total"`
+}
+
+// LinkUpdate defines model for LinkUpdate.
+type LinkUpdate struct {
+	Description *string `json:"description,omitempty"`
+	Image       *string `json:"image,omitempty"`
+
+	// Template Preview page template, default if omitted.
+	Template *string `json:"template,omitempty"`
+	Title    *string `json:"title,omitempty"`
+	Url      *string `json:"url,omitempty"`
+}
+
 // Metadata defines model for Metadata.
 type Metadata struct {
-	Description string `json:"description"`
-	Image       string `json:"image"`
-	Title       string `json:"title"`
+	Article *Article          `json:"article,omitempty"`
+	Audios  *[]OpenGraphAudio `json:"audios,omitempty"`
+	Book    *Book             `json:"book,omitempty"`
+
+	// CanonicalUrl og:url, falling back to link rel=canonical and then finalUrl.
+	CanonicalUrl *string `json:"canonicalUrl,omitempty"`
+	Description  string  `json:"description"`
+
+	// Favicon The best icon of the page.
+	Favicon *string `json:"favicon,omitempty"`
+
+	// FetchProfile Header profile the page was fetched with.
+	FetchProfile *string `json:"fetchProfile,omitempty"`
+
+	// FinalUrl The URL the page was served from after following redirects.
+	FinalUrl string `json:"finalUrl"`
+
+	// Icons Icons from link tags and the web app manifest, best first.
+	Icons *[]Icon `json:"icons,omitempty"`
+	Image string  `json:"image"`
+
+	// ImageProbes Candidate images in the order they were found, only present when probe=true.
+	ImageProbes *[]ImageProbe `json:"imageProbes,omitempty"`
+
+	// ImageSource Where image was found: opengraph, twitter, link (rel=image_src), jsonld, microdata
+	// (itemprop=image), content (a large image of the page) or oembed.
+	ImageSource *string           `json:"imageSource,omitempty"`
+	Images      *[]OpenGraphImage `json:"images,omitempty"`
+
+	// InvalidUrls URL-valued fields that could not be resolved and were dropped.
+	InvalidUrls *[]InvalidUrl `json:"invalidUrls,omitempty"`
+
+	// JsonLd Raw JSON-LD objects, only present when jsonld=true.
+	JsonLd *[]map[string]interface{} `json:"jsonLd,omitempty"`
+
+	// Locale og:locale
+	Locale *string `json:"locale,omitempty"`
+
+	// LocaleAlternates og:locale:alternate
+	LocaleAlternates *[]string `json:"localeAlternates,omitempty"`
+
+	// Oembed oEmbed data discovered from the page or the provider registry.
+	Oembed  *OEmbed  `json:"oembed,omitempty"`
+	Profile *Profile `json:"profile,omitempty"`
+
+	// Robots What the site's robots.txt says about the page, allowed or disallowed. Only present when
+	// the server checks robots.txt.
+	Robots *string `json:"robots,omitempty"`
+
+	// SiteName og:site_name
+	SiteName *string `json:"siteName,omitempty"`
+
+	// StructuredData Normalized subset of the page's JSON-LD (schema.org) data.
+	StructuredData *StructuredData `json:"structuredData,omitempty"`
+	Title          string          `json:"title"`
+
+	// Type og:type
+	Type   *string           `json:"type,omitempty"`
+	Url    string            `json:"url"`
+	Videos *[]OpenGraphVideo `json:"videos,omitempty"`
+}
+
+// MetadataBatchItem defines model for MetadataBatchItem.
+type MetadataBatchItem struct {
+	// Jsonld See the jsonld parameter of /metadata.
+	Jsonld *bool `json:"jsonld,omitempty"`
+
+	// Probe See the probe parameter of /metadata.
+	Probe *bool `json:"probe,omitempty"`
+
+	// Profile See the profile parameter of /metadata.
+	Profile *string `json:"profile,omitempty"`
+
+	// ProxyImages See the proxyImages parameter of /metadata.
+	ProxyImages *bool  `json:"proxyImages,omitempty"`
 	Url         string `json:"url"`
 }
 
+// MetadataBatchRequest defines model for MetadataBatchRequest.
+type MetadataBatchRequest struct {
+	Items []MetadataBatchItem `json:"items"`
+}
+
+// MetadataBatchResponse defines model for MetadataBatchResponse.
+type MetadataBatchResponse struct {
+	// Results One result per item, in input order.
+	Results []MetadataBatchResult `json:"results"`
+}
+
+// MetadataBatchResult defines model for MetadataBatchResult.
+type MetadataBatchResult struct {
+	Error *Error `json:"error,omitempty"`
+
+	// Index Position of the item in the request.
+	Index    int       `json:"index"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+	Url      string    `json:"url"`
+}
+
+// OEmbed oEmbed data discovered from the page or the provider registry.
+type OEmbed struct {
+	AuthorName *string `json:"authorName,omitempty"`
+	AuthorUrl  *string `json:"authorUrl,omitempty"`
+	Height     *int    `json:"height,omitempty"`
+
+	// Html Embeddable markup for video and rich types.
+	Html            *string `json:"html,omitempty"`
+	ProviderName    *string `json:"providerName,omitempty"`
+	ProviderUrl     *string `json:"providerUrl,omitempty"`
+	ThumbnailHeight *int    `json:"thumbnailHeight,omitempty"`
+	ThumbnailUrl    *string `json:"thumbnailUrl,omitempty"`
+	ThumbnailWidth  *int    `json:"thumbnailWidth,omitempty"`
+	Title           *string `json:"title,omitempty"`
+
+	// Type photo, video, link or rich
+	Type string `json:"type"`
+
+	// Url Image source for the photo type.
+	Url   *string `json:"url,omitempty"`
+	Width *int    `json:"width,omitempty"`
+}
+
+// OpenGraphAudio defines model for OpenGraphAudio.
+type OpenGraphAudio struct {
+	SecureUrl *string `json:"secureUrl,omitempty"`
+	Type      *string `json:"type,omitempty"`
+	Url       string  `json:"url"`
+}
+
+// OpenGraphImage defines model for OpenGraphImage.
+type OpenGraphImage struct {
+	Alt       *string `json:"alt,omitempty"`
+	Height    *int    `json:"height,omitempty"`
+	SecureUrl *string `json:"secureUrl,omitempty"`
+	Type      *string `json:"type,omitempty"`
+	Url       string  `json:"url"`
+	Width     *int    `json:"width,omitempty"`
+}
+
+// OpenGraphVideo defines model for OpenGraphVideo.
+type OpenGraphVideo struct {
+	Height    *int    `json:"height,omitempty"`
+	SecureUrl *string `json:"secureUrl,omitempty"`
+	Type      *string `json:"type,omitempty"`
+	Url       string  `json:"url"`
+	Width     *int    `json:"width,omitempty"`
+}
+
+// PreviewMode bot serves the preview page, human the response configured for people.
+type PreviewMode string
+
+// Profile defines model for Profile.
+type Profile struct {
+	FirstName *string `json:"firstName,omitempty"`
+	Gender    *string `json:"gender,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+	Username  *string `json:"username,omitempty"`
+}
+
+// StructuredData Normalized subset of the page's JSON-LD (schema.org) data.
+type StructuredData struct {
+	Authors       *[]string     `json:"authors,omitempty"`
+	Availability  *string       `json:"availability,omitempty"`
+	Breadcrumb    *[]Breadcrumb `json:"breadcrumb,omitempty"`
+	DateModified  *string       `json:"dateModified,omitempty"`
+	DatePublished *string       `json:"datePublished,omitempty"`
+	Description   *string       `json:"description,omitempty"`
+	Image         *string       `json:"image,omitempty"`
+	Name          *string       `json:"name,omitempty"`
+	Price         *string       `json:"price,omitempty"`
+	PriceCurrency *string       `json:"priceCurrency,omitempty"`
+	RatingCount   *int          `json:"ratingCount,omitempty"`
+	RatingValue   *float32      `json:"ratingValue,omitempty"`
+	Types         *[]string     `json:"types,omitempty"`
+}
+
+// ThumbnailLayout standard puts the image on the right of the text, hero covers the card with the
+// image and text shows the text and a large icon only.
+type ThumbnailLayout string
+
+// GetFaviconParams defines parameters for GetFavicon.
+type GetFaviconParams struct {
+	// Url The page whose icon you want.
+	Url string `form:"url" json:"url"`
+
+	// Size Icon size in pixels.
+	Size *int `form:"size,omitempty" json:"size,omitempty"`
+}
+
+// GetImageParams defines parameters for GetImage.
+type GetImageParams struct {
+	// Url The image to fetch.
+	Url string `form:"url" json:"url"`
+
+	// W Width in pixels. With only one dimension the aspect ratio is kept.
+	W *int `form:"w,omitempty" json:"w,omitempty"`
+
+	// H Height in pixels.
+	H *int `form:"h,omitempty" json:"h,omitempty"`
+
+	// Fit How the image fits w and h, cover if both are set and contain otherwise.
+	Fit *ImageFit `form:"fit,omitempty" json:"fit,omitempty"`
+
+	// Format Output format, the source format if it can be written and png otherwise.
+	Format *ImageFormat `form:"format,omitempty" json:"format,omitempty"`
+
+	// Quality JPEG quality, 85 if omitted.
+	Quality *int `form:"quality,omitempty" json:"quality,omitempty"`
+}
+
+// ServeLinkParams defines parameters for ServeLink.
+type ServeLinkParams struct {
+	// Mode Force the response for crawlers or people instead of detecting it from the User-Agent.
+	Mode *PreviewMode `form:"mode,omitempty" json:"mode,omitempty"`
+}
+
+// ListLinksParams defines parameters for ListLinks.
+type ListLinksParams struct {
+	// Limit Maximum number of links to return.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Offset Number of links to skip, newest first.
+	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
+}
+
 // GetMetadataParams defines parameters for GetMetadata.
 type GetMetadataParams struct {
 	// Url The URL for which you want to retrieve OpenGraph data.
 	Url string `form:"url" json:"url"`
+
+	// Jsonld Include the page's raw JSON-LD objects in the response.
+	Jsonld *bool `form:"jsonld,omitempty" json:"jsonld,omitempty"`
+
+	// ProxyImages Rewrite image URLs to go through the /image proxy.
+	ProxyImages *bool `form:"proxyImages,omitempty" json:"proxyImages,omitempty"`
+
+	// Probe Check the candidate images with a range request, report their real type and dimensions
+	// in imageProbes and set image to the best valid one.
+	Probe *bool `form:"probe,omitempty" json:"probe,omitempty"`
+
+	// Profile Header profile to fetch the page with: default, facebookexternalhit, twitterbot, browser
+	// or one configured on the server. Without it the domain rules and the server default apply,
+	// and an empty page is fetched again with the fallback profile.
+	Profile *string `form:"profile,omitempty" json:"profile,omitempty"`
+}
+
+// GetMetadataBatchParams defines parameters for GetMetadataBatch.
+type GetMetadataBatchParams struct {
+	// Stream Answer with application/x-ndjson, one MetadataBatchResult per line in completion order,
+	// instead of a single MetadataBatchResponse in input order.
+	Stream *bool `form:"stream,omitempty" json:"stream,omitempty"`
 }
 
 // OpenGraphParams defines parameters for OpenGraph.
@@ -38,16 +471,91 @@ type OpenGraphParams struct {
 
 	// Image Optional custom image to use for OpenGraph data.
 	Image *string `form:"image,omitempty" json:"image,omitempty"`
+
+	// Template Name of the preview page template, default if omitted.
+	Template *string `form:"template,omitempty" json:"template,omitempty"`
+
+	// Mode Force the response for crawlers or people instead of detecting it from the User-Agent.
+	Mode *PreviewMode `form:"mode,omitempty" json:"mode,omitempty"`
+}
+
+// GetThumbnailParams defines parameters for GetThumbnail.
+type GetThumbnailParams struct {
+	// Url The page to render a card for.
+	Url string `form:"url" json:"url"`
+
+	// Format Image format, png if omitted.
+	Format *ImageFormat `form:"format,omitempty" json:"format,omitempty"`
+
+	// Layout Card layout, standard for pages with an image and text otherwise if omitted.
+	Layout *ThumbnailLayout `form:"layout,omitempty" json:"layout,omitempty"`
+
+	// Background Background colour as hex, e.g. ffffff.
+	Background *string `form:"background,omitempty" json:"background,omitempty"`
+
+	// Foreground Text colour as hex, e.g. 111827.
+	Foreground *string `form:"foreground,omitempty" json:"foreground,omitempty"`
+
+	// Accent Colour of the site name and the bottom bar as hex, e.g. 2563eb.
+	Accent *string `form:"accent,omitempty" json:"accent,omitempty"`
+
+	// TitleSize Title font size in pixels, 64 if omitted.
+	TitleSize *int `form:"titleSize,omitempty" json:"titleSize,omitempty"`
+
+	// DescriptionSize Description font size in pixels, 32 if omitted.
+	DescriptionSize *int `form:"descriptionSize,omitempty" json:"descriptionSize,omitempty"`
 }
 
+// CreateLinkJSONRequestBody defines body for CreateLink for application/json ContentType.
+type CreateLinkJSONRequestBody = LinkInput
+
+// UpdateLinkJSONRequestBody defines body for UpdateLink for application/json ContentType.
+type UpdateLinkJSONRequestBody = LinkUpdate
+
+// GetMetadataBatchJSONRequestBody defines body for GetMetadataBatch for application/json ContentType.
+type GetMetadataBatchJSONRequestBody = MetadataBatchRequest
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Get the icon of a site
+	// (GET /favicon)
+	GetFavicon(ctx echo.Context, params GetFaviconParams) error
+	// Proxy an image
+	// (GET /image)
+	GetImage(ctx echo.Context, params GetImageParams) error
+	// Serve a short link
+	// (GET /l/{slug})
+	ServeLink(ctx echo.Context, slug string, params ServeLinkParams) error
+	// List short links
+	// (GET /links)
+	ListLinks(ctx echo.Context, params ListLinksParams) error
+	// Create a short link
+	// (POST /links)
+	CreateLink(ctx echo.Context) error
+	// Delete a short link
+	// (DELETE /links/{slug})
+	DeleteLink(ctx echo.Context, slug string) error
+	// Get a short link
+	// (GET /links/{slug})
+	GetLink(ctx echo.Context, slug string) error
+	// Update a short link
+	// (PATCH /links/{slug})
+	UpdateLink(ctx echo.Context, slug string) error
 	// Get metadata of a URL
 	// (GET /metadata)
 	GetMetadata(ctx echo.Context, params GetMetadataParams) error
+	// Get metadata of several URLs
+	// (POST /metadata/batch)
+	GetMetadataBatch(ctx echo.Context, params GetMetadataBatchParams) error
+	// Counters of the fetcher
+	// (GET /metrics)
+	GetMetrics(ctx echo.Context) error
 	// OpenGraph Data
 	// (GET /opengraph)
 	OpenGraph(ctx echo.Context, params OpenGraphParams) error
+	// Render a social card of a URL
+	// (GET /thumbnail)
+	GetThumbnail(ctx echo.Context, params GetThumbnailParams) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -55,6 +563,197 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
+// GetFavicon converts echo context to params.
+func (w *ServerInterfaceWrapper) GetFavicon(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetFaviconParams
+	// ------------- Required query parameter "url" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "url", ctx.QueryParams(), &params.Url)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter url: %s", err))
+	}
+
+	// ------------- Optional query parameter "size" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "size", ctx.QueryParams(), &params.Size)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter size: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetFavicon(ctx, params)
+	return err
+}
+
+// GetImage converts echo context to params.
+func (w *ServerInterfaceWrapper) GetImage(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetImageParams
+	// ------------- Required query parameter "url" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "url", ctx.QueryParams(), &params.Url)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter url: %s", err))
+	}
+
+	// ------------- Optional query parameter "w" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "w", ctx.QueryParams(), &params.W)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter w: %s", err))
+	}
+
+	// ------------- Optional query parameter "h" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "h", ctx.QueryParams(), &params.H)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter h: %s", err))
+	}
+
+	// ------------- Optional query parameter "fit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fit", ctx.QueryParams(), &params.Fit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fit: %s", err))
+	}
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", ctx.QueryParams(), &params.Format)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter format: %s", err))
+	}
+
+	// ------------- Optional query parameter "quality" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "quality", ctx.QueryParams(), &params.Quality)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter quality: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetImage(ctx, params)
+	return err
+}
+
+// ServeLink converts echo context to params.
+func (w *ServerInterfaceWrapper) ServeLink(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "slug" -------------
+	var slug string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "slug", runtime.ParamLocationPath, ctx.Param("slug"), &slug)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter slug: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ServeLinkParams
+	// ------------- Optional query parameter "mode" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "mode", ctx.QueryParams(), &params.Mode)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter mode: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ServeLink(ctx, slug, params)
+	return err
+}
+
+// ListLinks converts echo context to params.
+func (w *ServerInterfaceWrapper) ListLinks(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListLinksParams
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", ctx.QueryParams(), &params.Offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter offset: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListLinks(ctx, params)
+	return err
+}
+
+// CreateLink converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateLink(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateLink(ctx)
+	return err
+}
+
+// DeleteLink converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteLink(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "slug" -------------
+	var slug string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "slug", runtime.ParamLocationPath, ctx.Param("slug"), &slug)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter slug: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteLink(ctx, slug)
+	return err
+}
+
+// GetLink converts echo context to params.
+func (w *ServerInterfaceWrapper) GetLink(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "slug" -------------
+	var slug string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "slug", runtime.ParamLocationPath, ctx.Param("slug"), &slug)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter slug: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetLink(ctx, slug)
+	return err
+}
+
+// UpdateLink converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateLink(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "slug" -------------
+	var slug string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "slug", runtime.ParamLocationPath, ctx.Param("slug"), &slug)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter slug: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UpdateLink(ctx, slug)
+	return err
+}
+
 // GetMetadata converts echo context to params.
 func (w *ServerInterfaceWrapper) GetMetadata(ctx echo.Context) error {
 	var err error
@@ -68,11 +767,66 @@ func (w *ServerInterfaceWrapper) GetMetadata(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter url: %s", err))
 	}
 
+	// ------------- Optional query parameter "jsonld" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "jsonld", ctx.QueryParams(), &params.Jsonld)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter jsonld: %s", err))
+	}
+
+	// ------------- Optional query parameter "proxyImages" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "proxyImages", ctx.QueryParams(), &params.ProxyImages)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter proxyImages: %s", err))
+	}
+
+	// ------------- Optional query parameter "probe" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "probe", ctx.QueryParams(), &params.Probe)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter probe: %s", err))
+	}
+
+	// ------------- Optional query parameter "profile" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "profile", ctx.QueryParams(), &params.Profile)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter profile: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
 	err = w.Handler.GetMetadata(ctx, params)
 	return err
 }
 
+// GetMetadataBatch converts echo context to params.
+func (w *ServerInterfaceWrapper) GetMetadataBatch(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetMetadataBatchParams
+	// ------------- Optional query parameter "stream" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "stream", ctx.QueryParams(), &params.Stream)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter stream: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetMetadataBatch(ctx, params)
+	return err
+}
+
+// GetMetrics converts echo context to params.
+func (w *ServerInterfaceWrapper) GetMetrics(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetMetrics(ctx)
+	return err
+}
+
 // OpenGraph converts echo context to params.
 func (w *ServerInterfaceWrapper) OpenGraph(ctx echo.Context) error {
 	var err error
@@ -107,11 +861,92 @@ func (w *ServerInterfaceWrapper) OpenGraph(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter image: %s", err))
 	}
 
+	// ------------- Optional query parameter "template" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "template", ctx.QueryParams(), &params.Template)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter template: %s", err))
+	}
+
+	// ------------- Optional query parameter "mode" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "mode", ctx.QueryParams(), &params.Mode)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter mode: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
 	err = w.Handler.OpenGraph(ctx, params)
 	return err
 }
 
+// GetThumbnail converts echo context to params.
+func (w *ServerInterfaceWrapper) GetThumbnail(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetThumbnailParams
+	// ------------- Required query parameter "url" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "url", ctx.QueryParams(), &params.Url)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter url: %s", err))
+	}
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", ctx.QueryParams(), &params.Format)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter format: %s", err))
+	}
+
+	// ------------- Optional query parameter "layout" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "layout", ctx.QueryParams(), &params.Layout)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter layout: %s", err))
+	}
+
+	// ------------- Optional query parameter "background" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "background", ctx.QueryParams(), &params.Background)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter background: %s", err))
+	}
+
+	// ------------- Optional query parameter "foreground" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "foreground", ctx.QueryParams(), &params.Foreground)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter foreground: %s", err))
+	}
+
+	// ------------- Optional query parameter "accent" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "accent", ctx.QueryParams(), &params.Accent)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter accent: %s", err))
+	}
+
+	// ------------- Optional query parameter "titleSize" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "titleSize", ctx.QueryParams(), &params.TitleSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter titleSize: %s", err))
+	}
+
+	// ------------- Optional query parameter "descriptionSize" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "descriptionSize", ctx.QueryParams(), &params.DescriptionSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter descriptionSize: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetThumbnail(ctx, params)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -140,7 +975,18 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/favicon", wrapper.GetFavicon)
+	router.GET(baseURL+"/image", wrapper.GetImage)
+	router.GET(baseURL+"/l/:slug", wrapper.ServeLink)
+	router.GET(baseURL+"/links", wrapper.ListLinks)
+	router.POST(baseURL+"/links", wrapper.CreateLink)
+	router.DELETE(baseURL+"/links/:slug", wrapper.DeleteLink)
+	router.GET(baseURL+"/links/:slug", wrapper.GetLink)
+	router.PATCH(baseURL+"/links/:slug", wrapper.UpdateLink)
 	router.GET(baseURL+"/metadata", wrapper.GetMetadata)
+	router.POST(baseURL+"/metadata/batch", wrapper.GetMetadataBatch)
+	router.GET(baseURL+"/metrics", wrapper.GetMetrics)
 	router.GET(baseURL+"/opengraph", wrapper.OpenGraph)
+	router.GET(baseURL+"/thumbnail", wrapper.GetThumbnail)
 
 }
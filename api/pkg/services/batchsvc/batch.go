@@ -0,0 +1,152 @@
+package batchsvc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidInput is returned for an empty or oversized batch.
+var ErrInvalidInput = errors.New("invalid input")
+
+// Result is the outcome of one item of a batch.
+type Result struct {
+	Index    int
+	Item     routes.MetadataBatchItem
+	Metadata routes.Metadata
+	Err      error
+}
+
+// GetMetadataBatch fetches the metadata of every item and calls emit with
+// each result as it completes. emit is never called concurrently and every
+// item gets exactly one result, a timeout error if the deadline passed
+// before it was done.
+func (svc *BatchSvcImpl) GetMetadataBatch(ctx context.Context, items []routes.MetadataBatchItem, emit func(Result)) error {
+	if len(items) == 0 {
+		return errors.Wrap(ErrInvalidInput, "no items")
+	}
+	if len(items) > svc.opts.MaxItems {
+		return errors.Wrapf(ErrInvalidInput, "%d items exceed the limit of %d", len(items), svc.opts.MaxItems)
+	}
+	if svc.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, svc.opts.Timeout)
+		defer cancel()
+	}
+
+	work := make(chan int)
+	results := make(chan Result)
+	hosts := newHostLimiter(svc.opts.PerHost)
+	var wg sync.WaitGroup
+	for i := 0; i < minInt(maxInt(1, svc.opts.Workers), len(items)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range work {
+				results <- svc.fetch(ctx, hosts, index, items[index])
+			}
+		}()
+	}
+	go func() {
+		for index := range items {
+			work <- index
+		}
+		close(work)
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		emit(r)
+	}
+	return nil
+}
+
+// fetch gets the metadata of one item once its host has a free slot.
+func (svc *BatchSvcImpl) fetch(ctx context.Context, hosts *hostLimiter, index int, item routes.MetadataBatchItem) Result {
+	r := Result{Index: index, Item: item}
+	host := hostOf(item.Url)
+	if err := hosts.acquire(ctx, host); err != nil {
+		r.Err = fetcher.ContextError(item.Url, errors.Wrap(err, "waiting for the batch"))
+		return r
+	}
+	defer hosts.release(host)
+
+	r.Metadata, r.Err = svc.next.GetMetadata(ctx, routes.GetMetadataParams{
+		Url:         item.Url,
+		Jsonld:      item.Jsonld,
+		ProxyImages: item.ProxyImages,
+		Probe:       item.Probe,
+		Profile:     item.Profile,
+	})
+	return r
+}
+
+// hostLimiter bounds the number of concurrent fetches per host.
+type hostLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{limit: maxInt(1, limit), slots: make(map[string]chan struct{})}
+}
+
+// acquire waits for a slot of host or for ctx to be done.
+func (h *hostLimiter) acquire(ctx context.Context, host string) error {
+	h.mu.Lock()
+	slots, ok := h.slots[host]
+	if !ok {
+		slots = make(chan struct{}, h.limit)
+		h.slots[host] = slots
+	}
+	h.mu.Unlock()
+
+	// an expired batch must not start new fetches even if a slot is free
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *hostLimiter) release(host string) {
+	h.mu.Lock()
+	slots := h.slots[host]
+	h.mu.Unlock()
+	<-slots
+}
+
+// hostOf returns the lower-cased host of rawURL, or rawURL itself when it
+// does not parse so invalid items still fail on their own.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,187 @@
+package batchsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+)
+
+// fakeUpstream answers GetMetadata with the URL as the title after delay,
+// or waits for ctx if block is set, and records the concurrency per host.
+type fakeUpstream struct {
+	delay func(url string) time.Duration
+	block func(url string) bool
+
+	mu      sync.Mutex
+	running map[string]int
+	peak    map[string]int
+}
+
+func newFakeUpstream() *fakeUpstream {
+	return &fakeUpstream{running: make(map[string]int), peak: make(map[string]int)}
+}
+
+func (u *fakeUpstream) GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error) {
+	host := hostOf(params.Url)
+	u.mu.Lock()
+	u.running[host]++
+	if u.running[host] > u.peak[host] {
+		u.peak[host] = u.running[host]
+	}
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.running[host]--
+		u.mu.Unlock()
+	}()
+
+	if u.block != nil && u.block(params.Url) {
+		<-ctx.Done()
+		return routes.Metadata{}, fetcher.ContextError(params.Url, ctx.Err())
+	}
+	var delay time.Duration
+	if u.delay != nil {
+		delay = u.delay(params.Url)
+	}
+	select {
+	case <-time.After(delay):
+		return routes.Metadata{Title: params.Url}, nil
+	case <-ctx.Done():
+		return routes.Metadata{}, fetcher.ContextError(params.Url, ctx.Err())
+	}
+}
+
+func items(urls ...string) []routes.MetadataBatchItem {
+	items := make([]routes.MetadataBatchItem, len(urls))
+	for i, url := range urls {
+		items[i] = routes.MetadataBatchItem{Url: url}
+	}
+	return items
+}
+
+// collect runs a batch and returns its results by index, failing on
+// missing or duplicate results.
+func collect(t *testing.T, svc *BatchSvcImpl, batch []routes.MetadataBatchItem) ([]Result, []int) {
+	t.Helper()
+	results := make([]Result, len(batch))
+	seen := make([]bool, len(batch))
+	var order []int
+	err := svc.GetMetadataBatch(context.Background(), batch, func(r Result) {
+		if seen[r.Index] {
+			t.Errorf("item %d emitted twice", r.Index)
+		}
+		seen[r.Index] = true
+		results[r.Index] = r
+		order = append(order, r.Index)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("item %d has no result", i)
+		}
+	}
+	return results, order
+}
+
+func TestBatchResultsMatchItems(t *testing.T) {
+	upstream := newFakeUpstream()
+	// earlier items take longer, so they complete in reverse
+	upstream.delay = func(url string) time.Duration {
+		var i int
+		fmt.Sscanf(url, "https://host%d.example/", &i)
+		return time.Duration(5-i) * 10 * time.Millisecond
+	}
+	svc := Handler(logger.GetInstance(), upstream, DefaultOptions())
+	batch := items("https://host0.example/", "https://host1.example/", "https://host2.example/",
+		"https://host3.example/", "https://host4.example/")
+
+	results, order := collect(t, svc, batch)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("item %d: %v", i, r.Err)
+		}
+		if r.Item.Url != batch[i].Url || r.Metadata.Title != batch[i].Url {
+			t.Errorf("result %d is for %q with title %q, want %q", i, r.Item.Url, r.Metadata.Title, batch[i].Url)
+		}
+	}
+	if order[0] != 4 {
+		t.Errorf("results emitted in order %v, want completion order", order)
+	}
+}
+
+func TestBatchPerHostCap(t *testing.T) {
+	upstream := newFakeUpstream()
+	upstream.delay = func(string) time.Duration { return 20 * time.Millisecond }
+	opts := DefaultOptions()
+	opts.Workers = 8
+	opts.PerHost = 2
+	svc := Handler(logger.GetInstance(), upstream, opts)
+
+	var urls []string
+	for i := 0; i < 6; i++ {
+		urls = append(urls, fmt.Sprintf("https://busy.example/%d", i))
+	}
+	for i := 0; i < 4; i++ {
+		urls = append(urls, fmt.Sprintf("https://other%d.example/", i))
+	}
+	results, _ := collect(t, svc, items(urls...))
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("item %d: %v", i, r.Err)
+		}
+	}
+	if peak := upstream.peak["busy.example"]; peak != opts.PerHost {
+		t.Errorf("peak concurrency for busy.example = %d, want %d", peak, opts.PerHost)
+	}
+}
+
+func TestBatchDeadline(t *testing.T) {
+	upstream := newFakeUpstream()
+	upstream.block = func(url string) bool { return url != "https://fast.example/" }
+	opts := DefaultOptions()
+	opts.Workers = 2
+	opts.PerHost = 1
+	opts.Timeout = 50 * time.Millisecond
+	svc := Handler(logger.GetInstance(), upstream, opts)
+
+	// the slow host holds its only slot, so its second item is still
+	// queued when the deadline passes
+	batch := items("https://slow.example/1", "https://fast.example/", "https://slow.example/2", "https://hung.example/")
+	start := time.Now()
+	results, _ := collect(t, svc, batch)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("batch took %v past its deadline", elapsed)
+	}
+	if results[1].Err != nil {
+		t.Errorf("fast item: %v", results[1].Err)
+	}
+	for _, i := range []int{0, 2, 3} {
+		fetchErr, ok := fetcher.AsError(results[i].Err)
+		if !ok || fetchErr.Kind != fetcher.KindTimeout {
+			t.Errorf("item %d error = %v, want a timeout", i, results[i].Err)
+		}
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxItems = 2
+	svc := Handler(logger.GetInstance(), newFakeUpstream(), opts)
+	for _, batch := range [][]routes.MetadataBatchItem{nil, items("a", "b", "c")} {
+		err := svc.GetMetadataBatch(context.Background(), batch, func(Result) {
+			t.Error("result emitted for a rejected batch")
+		})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%d items: error = %v, want ErrInvalidInput", len(batch), err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package batchsvc
+
+import (
+	"context"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/spf13/pflag"
+)
+
+// Upstream is the service the items of a batch are fetched from.
+type Upstream interface {
+	GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error)
+}
+
+// BatchSvcImpl fetches the metadata of many URLs at once with bounded
+// concurrency.
+type BatchSvcImpl struct {
+	logger logger.Logger
+	opts   *Options
+	next   Upstream
+}
+
+// Options - configuration for BatchSvcImpl
+type Options struct {
+	MaxItems int
+	Workers  int
+	PerHost  int
+	Timeout  time.Duration
+}
+
+// DefaultOptions returns Options suited to rendering a page of links.
+func DefaultOptions() *Options {
+	return &Options{
+		MaxItems: 100,
+		Workers:  8,
+		PerHost:  2,
+		Timeout:  20 * time.Second,
+	}
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("batchOptions", pflag.ExitOnError)
+	flags.IntVar(&o.MaxItems, "batch-max-items", o.MaxItems, "maximum number of URLs in a batch request")
+	flags.IntVar(&o.Workers, "batch-workers", o.Workers, "number of URLs of a batch fetched concurrently")
+	flags.IntVar(&o.PerHost, "batch-per-host", o.PerHost, "number of URLs of a batch fetched concurrently from the same host")
+	flags.DurationVar(&o.Timeout, "batch-timeout", o.Timeout, "deadline for a whole batch, items still pending fail with a timeout")
+	return flags
+}
+
+func Handler(logger logger.Logger, next Upstream, opts *Options) *BatchSvcImpl {
+	return &BatchSvcImpl{
+		logger: logger,
+		opts:   opts,
+		next:   next,
+	}
+}
@@ -0,0 +1,217 @@
+package cachesvc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+)
+
+// result is a cached upstream response, either a value or a fetch failure.
+type result[V any] struct {
+	value V
+	err   error
+	// info describes the page fetch the value was built from.
+	info fetcher.PageInfo
+	// expires is when the result becomes stale.
+	expires time.Time
+	// status is how the shared lookup served the result, for every caller
+	// to record in its own context.
+	status cache.Status
+}
+
+// tier is a cache level behind the in-memory LRU.
+type tier[V any] interface {
+	get(ctx context.Context, key string) (*result[V], bool)
+	put(ctx context.Context, key string, r result[V])
+}
+
+func (svc *CacheSvcImpl) GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error) {
+	key := "metadata|" + cache.NormalizeURL(params.Url) + "|jsonld=" + strconv.FormatBool(params.Jsonld != nil && *params.Jsonld) +
+		"|probe=" + strconv.FormatBool(params.Probe != nil && *params.Probe) +
+		"|profile=" + optString(params.Profile)
+	var l2 tier[routes.Metadata]
+	if svc.store != nil {
+		l2 = &metadataTier{svc: svc, url: params.Url}
+	}
+	r := lookup(ctx, svc, svc.metadata, l2, key, params.Url, func(ctx context.Context) (routes.Metadata, error) {
+		return svc.next.GetMetadata(ctx, params)
+	})
+	return r.value, r.err
+}
+
+func (svc *CacheSvcImpl) OpenGraphEditor(ctx context.Context, params routes.OpenGraphParams) (string, error) {
+	key := "opengraph|" + cache.NormalizeURL(params.Url) +
+		"|title=" + optString(params.Title) +
+		"|description=" + optString(params.Description) +
+		"|image=" + optString(params.Image) +
+		"|template=" + optString(params.Template)
+	r := lookup(ctx, svc, svc.pages, nil, key, params.Url, func(ctx context.Context) (string, error) {
+		return svc.next.OpenGraphEditor(ctx, params)
+	})
+	return r.value, r.err
+}
+
+// GetFavicon is not cached, icons are large compared to metadata.
+func (svc *CacheSvcImpl) GetFavicon(ctx context.Context, params routes.GetFaviconParams) (string, []byte, error) {
+	return svc.next.GetFavicon(ctx, params)
+}
+
+// GetThumbnail is not cached for the same reason.
+func (svc *CacheSvcImpl) GetThumbnail(ctx context.Context, params routes.GetThumbnailParams) (string, []byte, error) {
+	return svc.next.GetThumbnail(ctx, params)
+}
+
+// lookup serves key, the cache key of rawURL, from lru, then from l2 if it
+// is not nil, or calls load once for all concurrent callers and caches its
+// result. Stale entries are returned immediately and refreshed in the
+// background, except for pages marked no-cache, which are revalidated before
+// use. The shared call is detached from the caller's cancellation so one
+// client going away does not fail the others; the fetcher's own timeouts
+// still bound it. A caller whose ctx ends first gets a timeout or canceled
+// fetch error.
+func lookup[V any](ctx context.Context, svc *CacheSvcImpl, lru *cache.LRU[result[V]], l2 tier[V], key, rawURL string, load func(context.Context) (V, error)) result[V] {
+	var stale *result[V]
+	if r, ok := lru.Get(key); ok {
+		if r.err != nil || time.Now().Before(r.expires) {
+			cache.SetStatus(ctx, cache.StatusHit)
+			return r
+		}
+		if !r.mustRevalidate() {
+			cache.SetStatus(ctx, cache.StatusStale)
+			refresh(ctx, svc, lru, l2, key, &r, load)
+			return r
+		}
+		stale = &r
+	}
+	cache.SetStatus(ctx, cache.StatusMiss)
+
+	ch := svc.group.DoChan(key, func() (interface{}, error) {
+		ctx := cache.Detach(ctx)
+		prev := stale
+		if prev == nil && l2 != nil {
+			if r, ok := l2.get(ctx, key); ok {
+				switch {
+				case time.Now().Before(r.expires):
+					lru.Set(key, *r, time.Until(r.expires)+svc.opts.StaleTTL)
+					r.status = cache.StatusHit
+					return *r, nil
+				case time.Now().Before(r.expires.Add(svc.opts.StaleTTL)) && !r.mustRevalidate():
+					refresh(ctx, svc, lru, l2, key, r, load)
+					r.status = cache.StatusStale
+					return *r, nil
+				}
+				prev = r
+			}
+		}
+		r := revalidate(ctx, svc, lru, l2, key, prev, load)
+		r.status = cache.StatusMiss
+		return r, nil
+	})
+	select {
+	case <-ctx.Done():
+		return result[V]{err: fetcher.ContextError(rawURL, ctx.Err())}
+	case res := <-ch:
+		// the shared call outlives its first caller, so only the waiting
+		// caller records the status in its context
+		r := res.Val.(result[V])
+		cache.SetStatus(ctx, r.status)
+		return r
+	}
+}
+
+// mustRevalidate reports whether the page of r was served with no-cache, so
+// r may only be used once the page is confirmed unchanged.
+func (r *result[V]) mustRevalidate() bool {
+	return r.err == nil && cache.ParseControl(r.info.CacheControl).NoCache
+}
+
+// refresh revalidates prev in the background unless a refresh of key is
+// already running.
+func refresh[V any](ctx context.Context, svc *CacheSvcImpl, lru *cache.LRU[result[V]], l2 tier[V], key string, prev *result[V], load func(context.Context) (V, error)) {
+	ctx = cache.Detach(ctx)
+	svc.group.DoChan("refresh|"+key, func() (interface{}, error) {
+		return revalidate(ctx, svc, lru, l2, key, prev, load), nil
+	})
+}
+
+// revalidate calls load, conditionally on the validators of prev if there
+// is one, and caches the result. A page that has not changed keeps the
+// value of prev and only has its expiry extended.
+func revalidate[V any](ctx context.Context, svc *CacheSvcImpl, lru *cache.LRU[result[V]], l2 tier[V], key string, prev *result[V], load func(context.Context) (V, error)) result[V] {
+	if prev != nil && prev.err == nil {
+		ctx = fetcher.WithValidators(ctx, prev.info.ETag, prev.info.LastModified)
+	}
+	ctx, info := fetcher.WithPageInfo(ctx)
+	value, err := load(ctx)
+	r := result[V]{value: value, err: err, info: *info}
+	if fetchErr, ok := fetcher.AsError(err); ok && fetchErr.Kind == fetcher.KindNotModified && prev != nil {
+		svc.logger.Debugw("page not modified", "key", key)
+		r = *prev
+		r.info.CacheControl = info.CacheControl
+		r.info.FetchedAt = info.FetchedAt
+		if info.ETag != "" {
+			r.info.ETag = info.ETag
+		}
+		if info.LastModified != "" {
+			r.info.LastModified = info.LastModified
+		}
+	}
+
+	ttl, ok := svc.ttl(r.err, r.info)
+	if !ok {
+		return r
+	}
+	r.expires = time.Now().Add(ttl)
+	if r.err != nil {
+		lru.Set(key, r, ttl)
+		return r
+	}
+	lru.Set(key, r, ttl+svc.opts.StaleTTL)
+	if l2 != nil {
+		l2.put(ctx, key, r)
+	}
+	return r
+}
+
+// ttl returns how long a result stays fresh and whether it may be cached
+// at all. Only fetch failures are cached negatively; internal errors are
+// retried. Successful results follow the page's Cache-Control header.
+func (svc *CacheSvcImpl) ttl(err error, info fetcher.PageInfo) (time.Duration, bool) {
+	if err != nil {
+		if fetchErr, ok := fetcher.AsError(err); ok {
+			// throttling and cancellation say nothing about the page
+			if fetchErr.Kind == fetcher.KindThrottled || fetchErr.Kind == fetcher.KindCanceled {
+				return 0, false
+			}
+			return svc.opts.NegativeTTL, svc.opts.NegativeTTL > 0
+		}
+		return 0, false
+	}
+	control := cache.ParseControl(info.CacheControl)
+	switch {
+	case control.NoStore:
+		return 0, false
+	case control.NoCache:
+		// keep the entry for its validators, lookup revalidates it before
+		// every use
+		return 0, true
+	case control.HasMaxAge:
+		if control.MaxAge > svc.opts.MaxTTL {
+			return svc.opts.MaxTTL, true
+		}
+		return control.MaxAge, true
+	}
+	return svc.opts.TTL, true
+}
+
+func optString(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%q", *s)
+}
@@ -0,0 +1,234 @@
+package cachesvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+)
+
+// blockingUpstream answers GetMetadata once release is closed.
+type blockingUpstream struct {
+	Upstream
+	started chan struct{}
+	release chan struct{}
+}
+
+func (u *blockingUpstream) GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error) {
+	close(u.started)
+	<-u.release
+	return routes.Metadata{Title: "title"}, nil
+}
+
+func TestLookupStatusPerCaller(t *testing.T) {
+	upstream := &blockingUpstream{started: make(chan struct{}), release: make(chan struct{})}
+	svc := Handler(logger.GetInstance(), upstream, nil, DefaultOptions())
+	params := routes.GetMetadataParams{Url: "https://example.com/"}
+
+	// the first caller starts the shared call and goes away
+	first, cancel := context.WithCancel(cache.WithStatus(context.Background()))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := svc.GetMetadata(first, params)
+		if fetchErr, ok := fetcher.AsError(err); !ok || fetchErr.Kind != fetcher.KindCanceled {
+			t.Errorf("canceled caller got %v, want %s", err, fetcher.KindCanceled)
+		}
+	}()
+	<-upstream.started
+
+	var wg sync.WaitGroup
+	statuses := make([]cache.Status, 3)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := cache.WithStatus(context.Background())
+			metadata, err := svc.GetMetadata(ctx, params)
+			if err != nil || metadata.Title != "title" {
+				t.Errorf("waiter got %+v, %v", metadata, err)
+			}
+			statuses[i] = cache.StatusFromContext(ctx)
+		}(i)
+	}
+	cancel()
+	<-done
+	// let the waiters join the shared call before it returns
+	time.Sleep(10 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	if status := cache.StatusFromContext(first); status != cache.StatusMiss {
+		t.Errorf("canceled caller status = %q, want MISS", status)
+	}
+	for i, status := range statuses {
+		if status != cache.StatusMiss {
+			t.Errorf("waiter %d status = %q, want MISS", i, status)
+		}
+	}
+
+	ctx := cache.WithStatus(context.Background())
+	if _, err := svc.GetMetadata(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+	if status := cache.StatusFromContext(ctx); status != cache.StatusHit {
+		t.Errorf("later caller status = %q, want HIT", status)
+	}
+}
+
+func TestLookupSharesL2Status(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, time.Hour)
+	upstream := &blockingUpstream{started: make(chan struct{}), release: make(chan struct{})}
+	svc := Handler(logger.GetInstance(), upstream, store, DefaultOptions())
+	params := routes.GetMetadataParams{Url: "https://example.com/"}
+
+	// a fresh entry left in the database by another replica
+	tier := &metadataTier{svc: svc, url: params.Url}
+	key := "metadata|" + cache.NormalizeURL(params.Url) + "|jsonld=false|probe=false|profile=-"
+	tier.put(ctx, key, result[routes.Metadata]{
+		value:   routes.Metadata{Title: "stored"},
+		expires: time.Now().Add(time.Minute),
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := cache.WithStatus(context.Background())
+			metadata, err := svc.GetMetadata(ctx, params)
+			if err != nil || metadata.Title != "stored" {
+				t.Errorf("got %+v, %v", metadata, err)
+			}
+			if status := cache.StatusFromContext(ctx); status != cache.StatusHit {
+				t.Errorf("status = %q, want HIT", status)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTTL(t *testing.T) {
+	svc := Handler(logger.GetInstance(), nil, nil, DefaultOptions())
+	fetchErr := func(kind fetcher.Kind) error {
+		return &fetcher.Error{Kind: kind, URL: "https://example.com/"}
+	}
+	tests := []struct {
+		name         string
+		err          error
+		cacheControl string
+		ttl          time.Duration
+		ok           bool
+	}{
+		{"default", nil, "", 10 * time.Minute, true},
+		{"max-age", nil, "max-age=60", time.Minute, true},
+		{"max-age capped", nil, "max-age=604800", 24 * time.Hour, true},
+		{"no-store", nil, "no-store, max-age=60", 0, false},
+		{"no-cache", nil, "no-cache", 0, true},
+		{"fetch failure", fetchErr(fetcher.KindUpstreamStatus), "max-age=600", 30 * time.Second, true},
+		{"timeout", fetchErr(fetcher.KindTimeout), "", 30 * time.Second, true},
+		{"throttled", fetchErr(fetcher.KindThrottled), "", 0, false},
+		{"canceled", fetchErr(fetcher.KindCanceled), "", 0, false},
+		{"internal error", errors.New("database down"), "", 0, false},
+	}
+	for _, tt := range tests {
+		ttl, ok := svc.ttl(tt.err, fetcher.PageInfo{CacheControl: tt.cacheControl})
+		if ttl != tt.ttl || ok != tt.ok {
+			t.Errorf("%s: ttl = %v, %v, want %v, %v", tt.name, ttl, ok, tt.ttl, tt.ok)
+		}
+	}
+
+	svc.opts.NegativeTTL = 0
+	if _, ok := svc.ttl(fetchErr(fetcher.KindUnreachable), fetcher.PageInfo{}); ok {
+		t.Error("fetch failure cached with a zero negative ttl")
+	}
+}
+
+// fetchingUpstream fetches the page of GetMetadata and uses its body as the
+// title.
+type fetchingUpstream struct {
+	Upstream
+	fetcher *fetcher.Fetcher
+}
+
+func (u *fetchingUpstream) GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error) {
+	res, err := u.fetcher.Fetch(ctx, params.Url)
+	if err != nil {
+		return routes.Metadata{}, err
+	}
+	return routes.Metadata{Title: string(res.Body)}, nil
+}
+
+func TestLookupRevalidatesNoCache(t *testing.T) {
+	var version, full, notModified int32
+	atomic.StoreInt32(&version, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v1"`
+		if atomic.LoadInt32(&version) == 2 {
+			etag = `"v2"`
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&full, 1)
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(etag))
+	}))
+	defer server.Close()
+
+	opts := fetcher.DefaultOptions()
+	opts.AllowCIDRs = []string{"127.0.0.1/32"}
+	f, err := fetcher.New(opts, logger.GetInstance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := Handler(logger.GetInstance(), &fetchingUpstream{fetcher: f}, nil, DefaultOptions())
+	params := routes.GetMetadataParams{Url: server.URL + "/"}
+
+	steps := []struct {
+		version     int32
+		title       string
+		full        int32
+		notModified int32
+	}{
+		{1, `"v1"`, 1, 0},
+		// unchanged, answered with 304 and served from the cache
+		{1, `"v1"`, 1, 1},
+		// changed, never served stale
+		{2, `"v2"`, 2, 1},
+	}
+	for i, step := range steps {
+		atomic.StoreInt32(&version, step.version)
+		ctx := cache.WithStatus(context.Background())
+		metadata, err := svc.GetMetadata(ctx, params)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if metadata.Title != step.title {
+			t.Errorf("step %d: title %s, want %s", i, metadata.Title, step.title)
+		}
+		if status := cache.StatusFromContext(ctx); status == cache.StatusStale {
+			t.Errorf("step %d: served STALE", i)
+		}
+		if got := atomic.LoadInt32(&full); got != step.full {
+			t.Errorf("step %d: %d full fetches, want %d", i, got, step.full)
+		}
+		if got := atomic.LoadInt32(&notModified); got != step.notModified {
+			t.Errorf("step %d: %d not modified answers, want %d", i, got, step.notModified)
+		}
+	}
+}
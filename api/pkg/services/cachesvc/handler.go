@@ -0,0 +1,77 @@
+package cachesvc
+
+import (
+	"context"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/spf13/pflag"
+	"golang.org/x/sync/singleflight"
+)
+
+// Upstream is the service whose results are cached.
+type Upstream interface {
+	OpenGraphEditor(ctx context.Context, params routes.OpenGraphParams) (string, error)
+	GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error)
+	GetFavicon(ctx context.Context, params routes.GetFaviconParams) (string, []byte, error)
+	GetThumbnail(ctx context.Context, params routes.GetThumbnailParams) (string, []byte, error)
+}
+
+// CacheSvcImpl caches the results of an Upstream in memory and, if a Store
+// is configured, metadata in the database behind it. Concurrent requests
+// for the same key share a single upstream call. Expired entries are served
+// stale while a conditional request revalidates them in the background.
+type CacheSvcImpl struct {
+	logger   logger.Logger
+	opts     *Options
+	next     Upstream
+	store    *Store
+	metadata *cache.LRU[result[routes.Metadata]]
+	pages    *cache.LRU[result[string]]
+	group    singleflight.Group
+}
+
+// Options - configuration for CacheSvcImpl
+type Options struct {
+	Size        int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+	StaleTTL    time.Duration
+	MaxTTL      time.Duration
+}
+
+// DefaultOptions returns the default cache configuration.
+func DefaultOptions() *Options {
+	return &Options{
+		Size:        1000,
+		TTL:         10 * time.Minute,
+		NegativeTTL: 30 * time.Second,
+		StaleTTL:    time.Hour,
+		MaxTTL:      24 * time.Hour,
+	}
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("cacheOptions", pflag.ExitOnError)
+	flags.IntVar(&o.Size, "cache-size", o.Size, "maximum number of entries per in-memory cache, 0 disables caching")
+	flags.DurationVar(&o.TTL, "cache-ttl", o.TTL, "how long successful lookups are cached when upstream sends no max-age")
+	flags.DurationVar(&o.NegativeTTL, "cache-negative-ttl", o.NegativeTTL, "how long failed lookups are cached")
+	flags.DurationVar(&o.StaleTTL, "cache-stale-ttl", o.StaleTTL, "how long expired lookups are served while they are refreshed in the background")
+	flags.DurationVar(&o.MaxTTL, "cache-max-ttl", o.MaxTTL, "upper bound on TTLs taken from upstream Cache-Control max-age")
+	return flags
+}
+
+// Handler - constructor for CacheSvcImpl, store may be nil
+func Handler(logger logger.Logger, next Upstream, store *Store, opts *Options) *CacheSvcImpl {
+	return &CacheSvcImpl{
+		logger:   logger,
+		opts:     opts,
+		next:     next,
+		store:    store,
+		metadata: cache.NewLRU[result[routes.Metadata]](opts.Size),
+		pages:    cache.NewLRU[result[string]](opts.Size),
+	}
+}
@@ -0,0 +1,154 @@
+package cachesvc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/models"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// purgeInterval is how often writes also delete entries past retention.
+const purgeInterval = 10 * time.Minute
+
+// Store persists metadata in the database so it survives restarts and is
+// shared between replicas. It sits behind the in-memory cache. Entries are
+// kept for retention after they expire, then purged by a later Put.
+type Store struct {
+	db        *gorm.DB
+	retention time.Duration
+
+	mu        sync.Mutex
+	nextPurge time.Time
+}
+
+// NewStore - constructor for Store
+func NewStore(db *gorm.DB, retention time.Duration) *Store {
+	return &Store{db: db, retention: retention}
+}
+
+// Get returns the entry for key, or nil if there is none. Expired entries
+// are returned too, their validators allow a conditional refresh.
+func (s *Store) Get(ctx context.Context, key string) (*models.MetadataEntry, error) {
+	var entry models.MetadataEntry
+	err := s.db.WithContext(ctx).
+		Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: storeKey(key)}).
+		Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading metadata entry")
+	}
+	return &entry, nil
+}
+
+// Put inserts or replaces the entry for key, and purges old entries if
+// that has not been done for purgeInterval.
+func (s *Store) Put(ctx context.Context, key string, entry *models.MetadataEntry) error {
+	entry.Key = storeKey(key)
+	err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(entry).Error
+	if err != nil {
+		return errors.Wrap(err, "writing metadata entry")
+	}
+	if s.purgeDue() {
+		if _, err := s.Purge(ctx, time.Now().Add(-s.retention)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge deletes the entries that expired before cutoff and returns how
+// many there were.
+func (s *Store) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).
+		Where(clause.Lt{Column: clause.Column{Name: "expires_at"}, Value: cutoff}).
+		Delete(&models.MetadataEntry{})
+	return res.RowsAffected, errors.Wrap(res.Error, "purging metadata entries")
+}
+
+func (s *Store) purgeDue() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Before(s.nextPurge) {
+		return false
+	}
+	s.nextPurge = now.Add(purgeInterval)
+	return true
+}
+
+// metadataTier adapts a Store to cache metadata behind the LRU.
+type metadataTier struct {
+	svc *CacheSvcImpl
+	url string
+}
+
+func (t *metadataTier) get(ctx context.Context, key string) (*result[routes.Metadata], bool) {
+	entry, err := t.svc.store.Get(ctx, key)
+	if err != nil {
+		t.svc.logger.Warnw("metadata store lookup failed", "url", t.url, "error", err)
+		return nil, false
+	}
+	if entry == nil {
+		return nil, false
+	}
+	r := &result[routes.Metadata]{
+		info: fetcher.PageInfo{
+			StatusCode:   entry.StatusCode,
+			ETag:         entry.ETag,
+			LastModified: entry.LastModified,
+			CacheControl: entry.CacheControl,
+			FetchedAt:    entry.FetchedAt,
+		},
+		expires: entry.ExpiresAt,
+	}
+	if err := json.Unmarshal(entry.Data, &r.value); err != nil {
+		t.svc.logger.Warnw("discarding undecodable metadata entry", "url", t.url, "error", err)
+		return nil, false
+	}
+	return r, true
+}
+
+// put persists r. Failures are logged, the in-memory cache still has r.
+func (t *metadataTier) put(ctx context.Context, key string, r result[routes.Metadata]) {
+	data, err := json.Marshal(r.value)
+	if err != nil {
+		t.svc.logger.Warnw("encoding metadata entry failed", "url", t.url, "error", err)
+		return
+	}
+	fetchedAt := r.info.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	err = t.svc.store.Put(ctx, key, &models.MetadataEntry{
+		URL:          t.url,
+		Data:         data,
+		StatusCode:   r.info.StatusCode,
+		ETag:         r.info.ETag,
+		LastModified: r.info.LastModified,
+		CacheControl: r.info.CacheControl,
+		FetchedAt:    fetchedAt,
+		ExpiresAt:    r.expires,
+	})
+	if err != nil {
+		t.svc.logger.Warnw("metadata store write failed", "url", t.url, "error", err)
+	}
+}
+
+// storeKey hashes key so long URLs fit in an indexed column.
+func storeKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
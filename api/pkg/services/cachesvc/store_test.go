@@ -0,0 +1,145 @@
+package cachesvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/models"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/database"
+)
+
+// newTestStore returns a Store backed by a fresh in-memory SQLite database.
+func newTestStore(t *testing.T, retention time.Duration) *Store {
+	t.Helper()
+	db, err := database.Connection(&database.Options{Driver: "sqlite", DSN: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection to :memory: would open a database of its own
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := models.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return NewStore(db, retention)
+}
+
+func countEntries(t *testing.T, s *Store) int64 {
+	t.Helper()
+	var n int64
+	if err := s.db.Model(&models.MetadataEntry{}).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestStoreGetPut(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, time.Hour)
+
+	entry, err := s.Get(ctx, "metadata|https://example.com/")
+	if err != nil || entry != nil {
+		t.Fatalf("Get of a missing key = %v, %v, want nil, nil", entry, err)
+	}
+
+	expires := time.Now().Add(time.Minute).Truncate(time.Second)
+	err = s.Put(ctx, "metadata|https://example.com/", &models.MetadataEntry{
+		URL:       "https://example.com/",
+		Data:      []byte(`{"title":"first"}`),
+		ETag:      `"v1"`,
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err = s.Get(ctx, "metadata|https://example.com/")
+	if err != nil || entry == nil {
+		t.Fatalf("Get = %v, %v", entry, err)
+	}
+	if string(entry.Data) != `{"title":"first"}` || entry.ETag != `"v1"` || !entry.ExpiresAt.Equal(expires) {
+		t.Errorf("Get = %+v", entry)
+	}
+	if entry, _ := s.Get(ctx, "metadata|https://example.org/"); entry != nil {
+		t.Errorf("Get of another key = %+v, want nil", entry)
+	}
+}
+
+func TestStorePutReplaces(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, time.Hour)
+	for _, title := range []string{"first", "second"} {
+		err := s.Put(ctx, "key", &models.MetadataEntry{
+			Data:      []byte(title),
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entry, err := s.Get(ctx, "key")
+	if err != nil || entry == nil || string(entry.Data) != "second" {
+		t.Fatalf("Get = %+v, %v, want the second write", entry, err)
+	}
+	if n := countEntries(t, s); n != 1 {
+		t.Errorf("%d rows after an upsert, want 1", n)
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, time.Hour)
+	now := time.Now()
+	entries := map[string]time.Time{
+		"fresh":   now.Add(time.Minute),
+		"expired": now.Add(-time.Minute),
+		"old":     now.Add(-2 * time.Hour),
+	}
+	for key, expires := range entries {
+		if err := s.db.Create(&models.MetadataEntry{Key: storeKey(key), ExpiresAt: expires}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// expired entries are still returned for revalidation
+	if entry, err := s.Get(ctx, "expired"); err != nil || entry == nil {
+		t.Fatalf("Get of an expired entry = %v, %v", entry, err)
+	}
+
+	// the first write purges what expired more than the retention ago
+	if err := s.Put(ctx, "new", &models.MetadataEntry{ExpiresAt: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"fresh": true, "expired": true, "old": false, "new": true} {
+		entry, err := s.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (entry != nil) != want {
+			t.Errorf("after purging, %s present = %v, want %v", key, entry != nil, want)
+		}
+	}
+
+	// later writes do not purge again until the interval has passed
+	if err := s.db.Create(&models.MetadataEntry{Key: storeKey("old again"), ExpiresAt: now.Add(-2 * time.Hour)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "newer", &models.MetadataEntry{ExpiresAt: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := s.Get(ctx, "old again"); entry == nil {
+		t.Error("a second write within the purge interval purged again")
+	}
+
+	n, err := s.Purge(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Purge deleted %d entries, want 2", n)
+	}
+}
@@ -0,0 +1,69 @@
+package imagesvc
+
+import (
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/diskcache"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/spf13/pflag"
+	"golang.org/x/sync/singleflight"
+)
+
+// ImageSvcImpl proxies images through the safe fetcher, resizing and
+// re-encoding them, and keeps the results in a disk cache.
+type ImageSvcImpl struct {
+	logger  logger.Logger
+	opts    *Options
+	fetcher *fetcher.Fetcher
+	cache   *diskcache.Cache
+	group   singleflight.Group
+}
+
+// Options - configuration for ImageSvcImpl
+type Options struct {
+	CacheDir       string
+	CacheMaxBytes  int64
+	MaxSourceBytes int64
+	MaxPixels      int
+	// TransformTimeout bounds fetching and transforming one image, which is
+	// shared by all requests for it.
+	TransformTimeout time.Duration
+}
+
+// DefaultOptions returns Options without a disk cache.
+func DefaultOptions() *Options {
+	return &Options{
+		CacheMaxBytes:    512 << 20,
+		MaxSourceBytes:   10 << 20,
+		MaxPixels:        40_000_000,
+		TransformTimeout: 30 * time.Second,
+	}
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("imageOptions", pflag.ExitOnError)
+	flags.StringVar(&o.CacheDir, "image-cache-dir", o.CacheDir, "directory caching proxied images, empty disables the cache")
+	flags.Int64Var(&o.CacheMaxBytes, "image-cache-max-bytes", o.CacheMaxBytes, "size of the image cache, least recently used images are evicted beyond it")
+	flags.Int64Var(&o.MaxSourceBytes, "image-max-source-bytes", o.MaxSourceBytes, "maximum size of an image fetched by the proxy")
+	flags.IntVar(&o.MaxPixels, "image-max-pixels", o.MaxPixels, "maximum number of pixels of an image decoded by the proxy")
+	flags.DurationVar(&o.TransformTimeout, "image-transform-timeout", o.TransformTimeout, "timeout for fetching and transforming an image, shared by concurrent requests for it")
+	return flags
+}
+
+func Handler(logger logger.Logger, fetcher *fetcher.Fetcher, opts *Options) (*ImageSvcImpl, error) {
+	svc := &ImageSvcImpl{
+		logger:  logger,
+		opts:    opts,
+		fetcher: fetcher,
+	}
+	if opts.CacheDir != "" {
+		cache, err := diskcache.New(opts.CacheDir, opts.CacheMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		svc.cache = cache
+	}
+	return svc, nil
+}
@@ -0,0 +1,216 @@
+package imagesvc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"net/http"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/imaging"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxDimension   = 4096
+	defaultQuality = 85
+)
+
+// ErrInvalidParameter is returned for request parameters out of range.
+var ErrInvalidParameter = errors.New("invalid parameter")
+
+// transform is a validated GetImage request.
+type transform struct {
+	width, height int
+	fit           routes.ImageFit
+	format        string
+	quality       int
+}
+
+// GetImage returns the image at params.Url transformed as requested.
+func (svc *ImageSvcImpl) GetImage(ctx context.Context, params routes.GetImageParams) (string, []byte, error) {
+	t, err := newTransform(params)
+	if err != nil {
+		return "", nil, err
+	}
+	key := fmt.Sprintf("%s|w=%d|h=%d|fit=%s|format=%s|quality=%d",
+		cache.NormalizeURL(params.Url), t.width, t.height, t.fit, t.format, t.quality)
+	if svc.cache != nil {
+		if data, ok := svc.cache.Get(key); ok {
+			cache.SetStatus(ctx, cache.StatusHit)
+			return http.DetectContentType(data), data, nil
+		}
+		cache.SetStatus(ctx, cache.StatusMiss)
+	}
+
+	// the transform is shared by every caller asking for key, so it runs
+	// detached from the first one and is bounded by its own timeout
+	ch := svc.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(cache.Detach(ctx), svc.opts.TransformTimeout)
+		defer cancel()
+		data, err := svc.transform(ctx, params.Url, t)
+		if err != nil {
+			return nil, err
+		}
+		if svc.cache != nil {
+			if err := svc.cache.Set(key, data); err != nil {
+				svc.logger.Warnw("image cache write failed", "url", params.Url, "error", err)
+			}
+		}
+		return data, nil
+	})
+	select {
+	case <-ctx.Done():
+		return "", nil, fetcher.ContextError(params.Url, ctx.Err())
+	case res := <-ch:
+		if res.Err != nil {
+			return "", nil, res.Err
+		}
+		data := res.Val.([]byte)
+		return http.DetectContentType(data), data, nil
+	}
+}
+
+func newTransform(params routes.GetImageParams) (transform, error) {
+	t := transform{quality: defaultQuality}
+	for _, d := range []struct {
+		name  string
+		value *int
+		dst   *int
+	}{
+		{"w", params.W, &t.width},
+		{"h", params.H, &t.height},
+	} {
+		if d.value == nil {
+			continue
+		}
+		if *d.value < 1 || *d.value > maxDimension {
+			return t, errors.Wrapf(ErrInvalidParameter, "%s must be between 1 and %d", d.name, maxDimension)
+		}
+		*d.dst = *d.value
+	}
+
+	t.fit = routes.Contain
+	if t.width > 0 && t.height > 0 {
+		t.fit = routes.Cover
+	}
+	if params.Fit != nil {
+		switch *params.Fit {
+		case routes.Cover, routes.Contain, routes.Crop:
+			t.fit = *params.Fit
+		default:
+			return t, errors.Wrapf(ErrInvalidParameter, "unknown fit %q", *params.Fit)
+		}
+	}
+
+	if params.Format != nil {
+		switch *params.Format {
+		case routes.Png:
+			t.format = imaging.FormatPNG
+		case routes.Jpeg:
+			t.format = imaging.FormatJPEG
+		case routes.Gif:
+			t.format = imaging.FormatGIF
+		default:
+			return t, errors.Wrapf(ErrInvalidParameter, "unknown format %q", *params.Format)
+		}
+	}
+
+	if params.Quality != nil {
+		if *params.Quality < 1 || *params.Quality > 100 {
+			return t, errors.Wrapf(ErrInvalidParameter, "quality must be between 1 and 100")
+		}
+		t.quality = *params.Quality
+	}
+	return t, nil
+}
+
+// transform fetches the image at imageURL and applies t to it.
+func (svc *ImageSvcImpl) transform(ctx context.Context, imageURL string, t transform) ([]byte, error) {
+	res, err := svc.fetcher.Do(ctx, &fetcher.Request{
+		URL:      imageURL,
+		Header:   http.Header{"Accept": []string{"image/*"}},
+		MaxBytes: svc.opts.MaxSourceBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	notImage := func(err error) error {
+		return &fetcher.Error{Kind: fetcher.KindNotImage, URL: imageURL, Err: err}
+	}
+	// check the dimensions before decoding to refuse decompression bombs
+	config, format, err := imaging.DecodeConfig(res.Body)
+	if err != nil {
+		return nil, notImage(err)
+	}
+	if config.Width*config.Height > svc.opts.MaxPixels {
+		return nil, &fetcher.Error{
+			Kind: fetcher.KindTooLarge,
+			URL:  imageURL,
+			Err:  errors.Errorf("image of %dx%d pixels exceeds %d pixels", config.Width, config.Height, svc.opts.MaxPixels),
+		}
+	}
+	img, _, err := imaging.Decode(res.Body)
+	if err != nil {
+		return nil, notImage(err)
+	}
+
+	img = resize(img, t)
+	outFormat := t.format
+	if outFormat == "" {
+		outFormat = sourceFormat(format)
+	}
+	if outFormat == imaging.FormatJPEG {
+		// JPEG has no transparency
+		img = imaging.Flatten(img, color.White)
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, outFormat, t.quality); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resize applies the dimensions and fit of t. A missing dimension follows
+// the aspect ratio of img.
+func resize(img image.Image, t transform) image.Image {
+	if t.width == 0 && t.height == 0 {
+		return img
+	}
+	b := img.Bounds()
+	width, height := t.width, t.height
+	if width == 0 {
+		width = maxInt(1, b.Dx()*height/maxInt(1, b.Dy()))
+	}
+	if height == 0 {
+		height = maxInt(1, b.Dy()*width/maxInt(1, b.Dx()))
+	}
+	switch t.fit {
+	case routes.Cover:
+		return imaging.Cover(img, width, height)
+	case routes.Crop:
+		return imaging.Crop(img, width, height)
+	default:
+		return imaging.Contain(img, width, height)
+	}
+}
+
+// sourceFormat keeps the format of the source when it can be written.
+func sourceFormat(format string) string {
+	switch format {
+	case imaging.FormatJPEG, imaging.FormatPNG, imaging.FormatGIF:
+		return format
+	}
+	return imaging.FormatPNG
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,229 @@
+package imagesvc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/imaging"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+)
+
+func newTestSvc(t *testing.T, opts *Options) *ImageSvcImpl {
+	t.Helper()
+	fetchOpts := fetcher.DefaultOptions()
+	fetchOpts.AllowCIDRs = []string{"127.0.0.1/32"}
+	f, err := fetcher.New(fetchOpts, logger.GetInstance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Handler(logger.GetInstance(), f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+// testImage is a width×height image, red on the left half and blue on the
+// right.
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestResize(t *testing.T) {
+	src := testImage(200, 100)
+	tests := []struct {
+		name  string
+		t     transform
+		wantW int
+		wantH int
+	}{
+		{name: "none", t: transform{}, wantW: 200, wantH: 100},
+		{name: "cover", t: transform{width: 50, height: 50, fit: routes.Cover}, wantW: 50, wantH: 50},
+		{name: "contain", t: transform{width: 50, height: 50, fit: routes.Contain}, wantW: 50, wantH: 25},
+		{name: "contain upscales", t: transform{width: 400, height: 400, fit: routes.Contain}, wantW: 400, wantH: 200},
+		{name: "crop", t: transform{width: 50, height: 50, fit: routes.Crop}, wantW: 50, wantH: 50},
+		{name: "crop clipped", t: transform{width: 300, height: 300, fit: routes.Crop}, wantW: 200, wantH: 100},
+		{name: "width only", t: transform{width: 100, fit: routes.Contain}, wantW: 100, wantH: 50},
+		{name: "height only", t: transform{height: 20, fit: routes.Contain}, wantW: 40, wantH: 20},
+		{name: "height only cover", t: transform{height: 20, fit: routes.Cover}, wantW: 40, wantH: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resize(src, tt.t).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("resized to %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+
+	// cover and crop keep the centre, so both halves stay visible
+	for _, fit := range []routes.ImageFit{routes.Cover, routes.Crop} {
+		img := resize(src, transform{width: 40, height: 40, fit: fit})
+		left, right := img.At(2, 20).(color.RGBA), img.At(37, 20).(color.RGBA)
+		if left.R != 255 || right.B != 255 {
+			t.Errorf("fit %s: left %v, right %v, want red and blue", fit, left, right)
+		}
+	}
+}
+
+func TestNewTransform(t *testing.T) {
+	fit := func(f routes.ImageFit) *routes.ImageFit { return &f }
+	format := func(f routes.ImageFormat) *routes.ImageFormat { return &f }
+	tests := []struct {
+		name    string
+		params  routes.GetImageParams
+		want    transform
+		wantErr bool
+	}{
+		{name: "defaults", want: transform{fit: routes.Contain, quality: defaultQuality}},
+		{
+			name:   "both dimensions cover",
+			params: routes.GetImageParams{W: intPtr(10), H: intPtr(20)},
+			want:   transform{width: 10, height: 20, fit: routes.Cover, quality: defaultQuality},
+		},
+		{
+			name:   "one dimension contains",
+			params: routes.GetImageParams{W: intPtr(10)},
+			want:   transform{width: 10, fit: routes.Contain, quality: defaultQuality},
+		},
+		{
+			name:   "explicit",
+			params: routes.GetImageParams{W: intPtr(10), H: intPtr(20), Fit: fit(routes.Crop), Format: format(routes.Jpeg), Quality: intPtr(50)},
+			want:   transform{width: 10, height: 20, fit: routes.Crop, format: imaging.FormatJPEG, quality: 50},
+		},
+		{name: "zero width", params: routes.GetImageParams{W: intPtr(0)}, wantErr: true},
+		{name: "huge height", params: routes.GetImageParams{H: intPtr(maxDimension + 1)}, wantErr: true},
+		{name: "unknown fit", params: routes.GetImageParams{Fit: fit("stretch")}, wantErr: true},
+		{name: "unknown format", params: routes.GetImageParams{Format: format("bmp")}, wantErr: true},
+		{name: "quality", params: routes.GetImageParams{Quality: intPtr(101)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTransform(tt.params)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidParameter) {
+					t.Errorf("error = %v, want ErrInvalidParameter", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("transform = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetImage(t *testing.T) {
+	var source bytes.Buffer
+	if err := png.Encode(&source, testImage(200, 100)); err != nil {
+		t.Fatal(err)
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(source.Bytes())
+	}))
+	defer server.Close()
+
+	opts := DefaultOptions()
+	opts.CacheDir = t.TempDir()
+	svc := newTestSvc(t, opts)
+	params := routes.GetImageParams{Url: server.URL + "/a.png", W: intPtr(50)}
+	for _, want := range []cache.Status{cache.StatusMiss, cache.StatusHit} {
+		ctx := cache.WithStatus(context.Background())
+		contentType, data, err := svc.GetImage(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := cache.StatusFromContext(ctx); status != want {
+			t.Errorf("status = %q, want %q", status, want)
+		}
+		if contentType != "image/png" {
+			t.Errorf("content type = %q, want image/png", contentType)
+		}
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Width != 50 || config.Height != 25 {
+			t.Errorf("image is %dx%d, want 50x25", config.Width, config.Height)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("fetched the source %d times, want once", n)
+	}
+}
+
+func TestGetImageOutlivesFirstCaller(t *testing.T) {
+	var source bytes.Buffer
+	if err := png.Encode(&source, testImage(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(source.Bytes())
+	}))
+	defer server.Close()
+	svc := newTestSvc(t, DefaultOptions())
+	params := routes.GetImageParams{Url: server.URL + "/a.png"}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, _, err := svc.GetImage(first, params)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan error)
+	go func() {
+		_, data, err := svc.GetImage(context.Background(), params)
+		if err == nil && len(data) == 0 {
+			err = errors.New("empty image")
+		}
+		second <- err
+	}()
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller got %v, want context.Canceled", err)
+	}
+	// let the second caller join the shared transform before it returns
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("waiting caller got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package linksvc
+
+import "github.com/pkg/errors"
+
+var (
+	// ErrNotFound is returned for slugs without a link.
+	ErrNotFound = errors.New("link not found")
+	// ErrInvalidInput is returned for links with an invalid URL or slug.
+	ErrInvalidInput = errors.New("invalid link")
+	// ErrSlugTaken is returned when a custom slug is already in use.
+	ErrSlugTaken = errors.New("slug already in use")
+	// ErrUnauthorized is returned when a request that needs a token has
+	// none.
+	ErrUnauthorized = errors.New("missing token")
+	// ErrForbidden is returned when the token of a request is neither the
+	// API key nor the owner token of the link it changes.
+	ErrForbidden = errors.New("invalid token")
+)
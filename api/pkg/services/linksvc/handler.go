@@ -0,0 +1,53 @@
+package linksvc
+
+import (
+	"context"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// Renderer builds the preview page of a URL with overrides.
+type Renderer interface {
+	OpenGraphEditor(ctx context.Context, params routes.OpenGraphParams) (string, error)
+}
+
+// LinkSvcImpl stores short links in the database and serves their previews
+// through a Renderer. A link can only be changed with the owner token
+// returned when it was created, or with the API key.
+type LinkSvcImpl struct {
+	logger   logger.Logger
+	opts     *Options
+	db       *gorm.DB
+	renderer Renderer
+}
+
+// Options - configuration for LinkSvcImpl
+type Options struct {
+	// APIKey allows listing every link and changing any of them. Listing
+	// is disabled when it is empty.
+	APIKey string
+}
+
+// DefaultOptions returns Options without an API key.
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("linkOptions", pflag.ExitOnError)
+	flags.StringVar(&o.APIKey, "links-api-key", o.APIKey, "bearer token allowed to list and change every short link, empty disables listing")
+	return flags
+}
+
+func Handler(logger logger.Logger, db *gorm.DB, renderer Renderer, opts *Options) *LinkSvcImpl {
+	return &LinkSvcImpl{
+		logger:   logger,
+		opts:     opts,
+		db:       db,
+		renderer: renderer,
+	}
+}
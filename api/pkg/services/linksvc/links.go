@@ -0,0 +1,313 @@
+package linksvc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"net/url"
+	"regexp"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/models"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	slugLength    = 7
+	slugAlphabet  = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	slugAttempts  = 5
+	defaultLimit  = 20
+	maxListLength = 100
+	tokenBytes    = 32
+)
+
+var (
+	slugPattern     = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+	templatePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// CreateLink stores a new link. The returned link carries its owner
+// token, which is not stored and cannot be retrieved again.
+func (svc *LinkSvcImpl) CreateLink(ctx context.Context, input routes.LinkInput) (routes.Link, error) {
+	link := &models.Link{
+		URL:         input.Url,
+		Title:       override(input.Title),
+		Description: override(input.Description),
+		Image:       override(input.Image),
+		Template:    override(input.Template),
+	}
+	if err := validate(link); err != nil {
+		return routes.Link{}, err
+	}
+	token, err := newToken()
+	if err != nil {
+		return routes.Link{}, err
+	}
+	link.TokenHash = hashToken(token)
+
+	if input.Slug != nil {
+		if !slugPattern.MatchString(*input.Slug) {
+			return routes.Link{}, errors.Wrapf(ErrInvalidInput, "slug %q must be 3 to 64 letters, digits, dashes or underscores", *input.Slug)
+		}
+		link.Slug = *input.Slug
+		if err := svc.db.WithContext(ctx).Create(link).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return routes.Link{}, errors.Wrapf(ErrSlugTaken, "creating link %q", link.Slug)
+			}
+			return routes.Link{}, errors.Wrap(err, "creating link")
+		}
+		return withToken(toLink(link), token), nil
+	}
+
+	// generated slugs rarely collide, retry a few times when they do
+	for attempt := 0; ; attempt++ {
+		slug, err := newSlug()
+		if err != nil {
+			return routes.Link{}, err
+		}
+		link.Slug = slug
+		err = svc.db.WithContext(ctx).Create(link).Error
+		if err == nil {
+			return withToken(toLink(link), token), nil
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == slugAttempts-1 {
+			return routes.Link{}, errors.Wrap(err, "creating link")
+		}
+		link.ID = 0
+	}
+}
+
+// ListLinks returns a page of every link, to holders of the API key only.
+func (svc *LinkSvcImpl) ListLinks(ctx context.Context, token string, params routes.ListLinksParams) (routes.LinkList, error) {
+	if token == "" {
+		return routes.LinkList{}, errors.Wrap(ErrUnauthorized, "listing links needs the API key")
+	}
+	if !svc.isAPIKey(token) {
+		return routes.LinkList{}, errors.Wrap(ErrForbidden, "listing links needs the API key")
+	}
+	limit, offset := defaultLimit, 0
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if params.Offset != nil {
+		offset = *params.Offset
+	}
+	if limit < 1 || limit > maxListLength || offset < 0 {
+		return routes.LinkList{}, errors.Wrapf(ErrInvalidInput, "limit must be 1 to %d and offset at least 0", maxListLength)
+	}
+
+	var total int64
+	if err := svc.db.WithContext(ctx).Model(&models.Link{}).Count(&total).Error; err != nil {
+		return routes.LinkList{}, errors.Wrap(err, "counting links")
+	}
+	var links []models.Link
+	err := svc.db.WithContext(ctx).
+		Order("created_at DESC").Order("id DESC").
+		Limit(limit).Offset(offset).
+		Find(&links).Error
+	if err != nil {
+		return routes.LinkList{}, errors.Wrap(err, "listing links")
+	}
+
+	list := routes.LinkList{
+		Links: make([]routes.Link, 0, len(links)),
+		Total: int(total),
+	}
+	for i := range links {
+		list.Links = append(list.Links, toLink(&links[i]))
+	}
+	return list, nil
+}
+
+func (svc *LinkSvcImpl) GetLink(ctx context.Context, slug string) (routes.Link, error) {
+	link, err := svc.find(ctx, slug)
+	if err != nil {
+		return routes.Link{}, err
+	}
+	return toLink(link), nil
+}
+
+// UpdateLink changes the fields present in update. An empty override
+// removes it, the page's own value is then used again. token must be the
+// link's owner token or the API key.
+func (svc *LinkSvcImpl) UpdateLink(ctx context.Context, slug, token string, update routes.LinkUpdate) (routes.Link, error) {
+	link, err := svc.find(ctx, slug)
+	if err != nil {
+		return routes.Link{}, err
+	}
+	if err := svc.authorize(link, token); err != nil {
+		return routes.Link{}, err
+	}
+	if update.Url != nil {
+		link.URL = *update.Url
+	}
+	if update.Title != nil {
+		link.Title = override(update.Title)
+	}
+	if update.Description != nil {
+		link.Description = override(update.Description)
+	}
+	if update.Image != nil {
+		link.Image = override(update.Image)
+	}
+	if update.Template != nil {
+		link.Template = override(update.Template)
+	}
+	if err := validate(link); err != nil {
+		return routes.Link{}, err
+	}
+	if err := svc.db.WithContext(ctx).Save(link).Error; err != nil {
+		return routes.Link{}, errors.Wrap(err, "updating link")
+	}
+	return toLink(link), nil
+}
+
+// DeleteLink removes a link, token must be its owner token or the API key.
+func (svc *LinkSvcImpl) DeleteLink(ctx context.Context, slug, token string) error {
+	link, err := svc.find(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if err := svc.authorize(link, token); err != nil {
+		return err
+	}
+	res := svc.db.WithContext(ctx).Delete(link)
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "deleting link")
+	}
+	if res.RowsAffected == 0 {
+		return errors.Wrapf(ErrNotFound, "slug %q", slug)
+	}
+	return nil
+}
+
+// authorize checks that token may change link.
+func (svc *LinkSvcImpl) authorize(link *models.Link, token string) error {
+	if token == "" {
+		return errors.Wrapf(ErrUnauthorized, "slug %q", link.Slug)
+	}
+	if svc.isAPIKey(token) {
+		return nil
+	}
+	// links created before owner tokens existed have no hash
+	if link.TokenHash == "" ||
+		subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(link.TokenHash)) != 1 {
+		return errors.Wrapf(ErrForbidden, "slug %q", link.Slug)
+	}
+	return nil
+}
+
+func (svc *LinkSvcImpl) isAPIKey(token string) bool {
+	return svc.opts.APIKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(svc.opts.APIKey)) == 1
+}
+
+// ServeLink returns the preview page of the link.
+func (svc *LinkSvcImpl) ServeLink(ctx context.Context, slug string) (string, error) {
+	link, err := svc.find(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	return svc.renderer.OpenGraphEditor(ctx, routes.OpenGraphParams{
+		Url:         link.URL,
+		Title:       link.Title,
+		Description: link.Description,
+		Image:       link.Image,
+		Template:    link.Template,
+	})
+}
+
+func (svc *LinkSvcImpl) find(ctx context.Context, slug string) (*models.Link, error) {
+	var link models.Link
+	err := svc.db.WithContext(ctx).Where("slug = ?", slug).Take(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Wrapf(ErrNotFound, "slug %q", slug)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding link")
+	}
+	return &link, nil
+}
+
+// validate checks that the target and image of link are absolute web URLs
+// and that its template has a valid name.
+func validate(link *models.Link) error {
+	if !isWebURL(link.URL) {
+		return errors.Wrapf(ErrInvalidInput, "url %q must be an absolute http or https URL", link.URL)
+	}
+	if link.Image != nil && !isWebURL(*link.Image) {
+		return errors.Wrapf(ErrInvalidInput, "image %q must be an absolute http or https URL", *link.Image)
+	}
+	// whether the template exists is only known when the link is served
+	if link.Template != nil && !templatePattern.MatchString(*link.Template) {
+		return errors.Wrapf(ErrInvalidInput, "template %q is not a valid template name", *link.Template)
+	}
+	return nil
+}
+
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func newSlug() (string, error) {
+	max := big.NewInt(int64(len(slugAlphabet)))
+	slug := make([]byte, slugLength)
+	for i := range slug {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "generating slug")
+		}
+		slug[i] = slugAlphabet[n.Int64()]
+	}
+	return string(slug), nil
+}
+
+// newToken returns a random owner token.
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generating token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the stored form of an owner token. Tokens are random,
+// so a plain hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// withToken returns link with its owner token.
+func withToken(link routes.Link, token string) routes.Link {
+	link.Token = &token
+	return link
+}
+
+// override treats an empty value as no override.
+func override(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+// toLink converts a stored link. ShortUrl is left to the caller, which
+// knows the host the API is served on.
+func toLink(link *models.Link) routes.Link {
+	return routes.Link{
+		Slug:        link.Slug,
+		Url:         link.URL,
+		Title:       link.Title,
+		Description: link.Description,
+		Image:       link.Image,
+		Template:    link.Template,
+		CreatedAt:   link.CreatedAt,
+		UpdatedAt:   link.UpdatedAt,
+	}
+}
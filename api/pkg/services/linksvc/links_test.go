@@ -0,0 +1,120 @@
+package linksvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/models"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/database"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const testAPIKey = "api-key"
+
+// newTestSvc returns a LinkSvcImpl with the API key testAPIKey, backed by
+// a fresh in-memory SQLite database.
+func newTestSvc(t *testing.T) *LinkSvcImpl {
+	t.Helper()
+	db, err := database.Connection(&database.Options{Driver: "sqlite", DSN: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection to :memory: would open a database of its own
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := models.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return Handler(logger.GetInstance(), db, nil, &Options{APIKey: testAPIKey})
+}
+
+func createLink(t *testing.T, svc *LinkSvcImpl, slug string) string {
+	t.Helper()
+	link, err := svc.CreateLink(context.Background(), routes.LinkInput{Url: "https://example.com/" + slug, Slug: &slug})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Token == nil || *link.Token == "" {
+		t.Fatalf("link %q created without a token", slug)
+	}
+	return *link.Token
+}
+
+func TestLinkAuthorization(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestSvc(t)
+	owner := createLink(t, svc, "mine")
+	other := createLink(t, svc, "theirs")
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"owner token", owner, nil},
+		{"API key", testAPIKey, nil},
+		{"no token", "", ErrUnauthorized},
+		{"wrong token", "guess", ErrForbidden},
+		{"token of another link", other, ErrForbidden},
+	}
+	for _, tt := range tests {
+		title := tt.name
+		_, err := svc.UpdateLink(ctx, "mine", tt.token, routes.LinkUpdate{Title: &title})
+		if !errors.Is(err, tt.err) || (err != nil) != (tt.err != nil) {
+			t.Errorf("update with %s: %v, want %v", tt.name, err, tt.err)
+		}
+		link, err := svc.GetLink(ctx, "mine")
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := link.Title != nil && *link.Title == title
+		if changed != (tt.err == nil) {
+			t.Errorf("update with %s: title %v", tt.name, link.Title)
+		}
+	}
+
+	for _, token := range []string{"", "guess", other} {
+		if err := svc.DeleteLink(ctx, "mine", token); err == nil {
+			t.Errorf("delete with %q succeeded", token)
+		}
+	}
+	if err := svc.DeleteLink(ctx, "mine", owner); err != nil {
+		t.Errorf("delete with the owner token: %v", err)
+	}
+	if err := svc.DeleteLink(ctx, "theirs", testAPIKey); err != nil {
+		t.Errorf("delete with the API key: %v", err)
+	}
+	if _, err := svc.GetLink(ctx, "theirs"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted link: %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestListLinksAuthorization(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestSvc(t)
+	owner := createLink(t, svc, "mine")
+
+	list, err := svc.ListLinks(ctx, testAPIKey, routes.ListLinksParams{})
+	if err != nil || list.Total != 1 || len(list.Links) != 1 {
+		t.Errorf("list with the API key = %+v, %v", list, err)
+	} else if list.Links[0].Token != nil {
+		t.Error("listed link carries its token")
+	}
+	for token, want := range map[string]error{"": ErrUnauthorized, "guess": ErrForbidden, owner: ErrForbidden} {
+		if _, err := svc.ListLinks(ctx, token, routes.ListLinksParams{}); !errors.Is(err, want) {
+			t.Errorf("list with %q: %v, want %v", token, err, want)
+		}
+	}
+
+	// without an API key listing is off for everybody
+	svc.opts.APIKey = ""
+	if _, err := svc.ListLinks(ctx, "", routes.ListLinksParams{}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("list without a key configured: %v, want %v", err, ErrUnauthorized)
+	}
+}
@@ -0,0 +1,227 @@
+package opengraphsvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/imaging"
+	"github.com/pkg/errors"
+)
+
+const (
+	manifestMaxBytes = 256 << 10
+	iconMaxBytes     = 512 << 10
+	maxIconSize      = 1024
+)
+
+// ErrInvalidParameter is returned for request parameters out of range.
+var ErrInvalidParameter = errors.New("invalid parameter")
+
+// manifest is the part of a web app manifest describing icons.
+type manifest struct {
+	Icons []struct {
+		Src     string `json:"src"`
+		Sizes   string `json:"sizes"`
+		Type    string `json:"type"`
+		Purpose string `json:"purpose"`
+	} `json:"icons"`
+}
+
+// GetFavicon returns the icon of params.Url that best fits params.Size,
+// falling back to /favicon.ico when the page declares none that loads. With
+// a size, raster icons are scaled to fit it, see resizeIcon.
+func (svc *OpenGraphSvcImpl) GetFavicon(ctx context.Context, params routes.GetFaviconParams) (string, []byte, error) {
+	var size int
+	if params.Size != nil {
+		size = *params.Size
+		if size < 1 || size > maxIconSize {
+			return "", nil, errors.Wrapf(ErrInvalidParameter, "size must be between 1 and %d", maxIconSize)
+		}
+	}
+	siteURL := params.Url
+	var icons []extractor.Icon
+	doc, res, err := svc.fetchDocument(ctx, params.Url)
+	switch kind := fetchErrorKind(err); {
+	case err == nil:
+		meta := extractor.Extract(doc, res.FinalURL, svc.extract)
+		meta.AddIcons(svc.manifestIcons(ctx, meta))
+		icons = meta.Icons
+		siteURL = res.FinalURL.String()
+	case kind == fetcher.KindInvalidURL, kind == fetcher.KindBlocked:
+		return "", nil, err
+	default:
+		svc.logger.Debugw("favicon page fetch failed, trying /favicon.ico", "url", params.Url, "error", err)
+	}
+
+	candidates := iconURLs(icons, size)
+	if fallback, err := url.Parse(siteURL); err == nil {
+		candidates = append(candidates, fallback.ResolveReference(&url.URL{Path: "/favicon.ico"}).String())
+	}
+
+	lastErr := error(&fetcher.Error{Kind: fetcher.KindNotImage, URL: params.Url, Err: errors.New("no icon found")})
+	for _, candidate := range candidates {
+		contentType, data, err := svc.fetchImage(ctx, candidate, iconMaxBytes)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if size > 0 {
+			contentType, data = svc.resizeIcon(candidate, contentType, data, size)
+		}
+		return contentType, data, nil
+	}
+	return "", nil, lastErr
+}
+
+// resizeIcon scales a raster icon to fit within size×size, keeping its
+// aspect ratio, and encodes it as PNG. Icons that already fit exactly are
+// kept, and so are formats without a Go decoder, such as SVG and ICO, and
+// icons of more than Options.ThumbnailMaxPixels.
+func (svc *OpenGraphSvcImpl) resizeIcon(iconURL, contentType string, data []byte, size int) (string, []byte) {
+	config, _, err := imaging.DecodeConfig(data)
+	if err != nil || maxInt(config.Width, config.Height) == size {
+		return contentType, data
+	}
+	if int64(config.Width)*int64(config.Height) > int64(svc.opts.ThumbnailMaxPixels) {
+		svc.logger.Debugw("icon too large to resize", "url", iconURL, "width", config.Width, "height", config.Height)
+		return contentType, data
+	}
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		svc.logger.Debugw("icon decode failed", "url", iconURL, "error", err)
+		return contentType, data
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, imaging.Contain(img, size, size), imaging.FormatPNG, 0); err != nil {
+		svc.logger.Warnw("icon encode failed", "url", iconURL, "error", err)
+		return contentType, data
+	}
+	return imaging.ContentType(imaging.FormatPNG), buf.Bytes()
+}
+
+// iconURLs orders icons for the requested size: the smallest icon at least
+// that large first, then larger-to-smaller for the rest. Without a size the
+// ranking is kept as is.
+func iconURLs(icons []extractor.Icon, size int) []string {
+	ordered := append([]extractor.Icon(nil), icons...)
+	if size > 0 {
+		sort.SliceStable(ordered, func(a, b int) bool {
+			fitsA, fitsB := ordered[a].EffectiveSize() >= size, ordered[b].EffectiveSize() >= size
+			if fitsA != fitsB {
+				return fitsA
+			}
+			if fitsA {
+				return ordered[a].EffectiveSize() < ordered[b].EffectiveSize()
+			}
+			return ordered[a].EffectiveSize() > ordered[b].EffectiveSize()
+		})
+	}
+	urls := make([]string, 0, len(ordered))
+	for _, icon := range ordered {
+		urls = append(urls, icon.URL)
+	}
+	return urls
+}
+
+// manifestIcons returns the icons of the page's web app manifest.
+func (svc *OpenGraphSvcImpl) manifestIcons(ctx context.Context, meta *extractor.Metadata) []extractor.Icon {
+	link := meta.FindLink("manifest")
+	if link == nil {
+		return nil
+	}
+	res, err := svc.fetcher.Do(ctx, &fetcher.Request{
+		URL:      link.Href,
+		Header:   http.Header{"Accept": []string{"application/manifest+json, application/json"}},
+		MaxBytes: manifestMaxBytes,
+	})
+	if err != nil {
+		svc.logger.Debugw("manifest fetch failed", "url", link.Href, "error", err)
+		return nil
+	}
+	var m manifest
+	if err := json.Unmarshal(res.Body, &m); err != nil {
+		svc.logger.Debugw("invalid manifest", "url", link.Href, "error", err)
+		return nil
+	}
+	var icons []extractor.Icon
+	for _, icon := range m.Icons {
+		if icon.Src == "" || strings.TrimSpace(icon.Purpose) == "monochrome" {
+			continue
+		}
+		src := meta.ResolveURL(icon.Src, "manifest:icon", res.FinalURL)
+		if src == "" {
+			continue
+		}
+		icons = append(icons, extractor.NewIcon(src, "manifest", icon.Type, icon.Sizes, extractor.SourceManifest))
+	}
+	return icons
+}
+
+// fetchImage downloads an image and returns its content type and bytes.
+func (svc *OpenGraphSvcImpl) fetchImage(ctx context.Context, imageURL string, maxBytes int64) (string, []byte, error) {
+	res, err := svc.fetcher.Do(ctx, &fetcher.Request{
+		URL:      imageURL,
+		Header:   http.Header{"Accept": []string{"image/*"}},
+		MaxBytes: maxBytes,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	contentType, ok := imageContentType(res)
+	if !ok {
+		return "", nil, &fetcher.Error{
+			Kind: fetcher.KindNotImage,
+			URL:  imageURL,
+			Err:  errors.Errorf("unexpected content type %q", contentType),
+		}
+	}
+	return contentType, res.Body, nil
+}
+
+// imageContentType returns the image type of res, trusting the body over
+// the declared Content-Type since servers often mislabel images.
+func imageContentType(res *fetcher.Response) (string, bool) {
+	sniffed := http.DetectContentType(res.Body)
+	if strings.HasPrefix(sniffed, "image/") {
+		return sniffed, true
+	}
+	declared, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if declared == "image/svg+xml" {
+		return declared, true
+	}
+	// SVG sniffs as XML
+	if strings.HasPrefix(sniffed, "text/xml") && bytes.Contains(res.Body[:minInt(len(res.Body), 1024)], []byte("<svg")) {
+		return "image/svg+xml", true
+	}
+	return declared, false
+}
+
+func fetchErrorKind(err error) fetcher.Kind {
+	if fetchErr, ok := fetcher.AsError(err); ok {
+		return fetchErr.Kind
+	}
+	return ""
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,111 @@
+package opengraphsvc
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/pkg/errors"
+)
+
+func TestIconURLs(t *testing.T) {
+	icons := []extractor.Icon{
+		extractor.NewIcon("/512.png", "icon", "image/png", "512x512", extractor.SourceLink),
+		extractor.NewIcon("/apple.png", "apple-touch-icon", "", "", extractor.SourceLink),
+		extractor.NewIcon("/64.png", "icon", "image/png", "64x64", extractor.SourceLink),
+		extractor.NewIcon("/16.png", "icon", "image/png", "16x16", extractor.SourceLink),
+	}
+	tests := []struct {
+		size int
+		want []string
+	}{
+		// without a size the ranking is kept
+		{0, []string{"/512.png", "/apple.png", "/64.png", "/16.png"}},
+		// the smallest icon that is large enough, then the largest of the rest
+		{48, []string{"/64.png", "/apple.png", "/512.png", "/16.png"}},
+		{180, []string{"/apple.png", "/512.png", "/64.png", "/16.png"}},
+		{1024, []string{"/512.png", "/apple.png", "/64.png", "/16.png"}},
+		{16, []string{"/16.png", "/64.png", "/apple.png", "/512.png"}},
+	}
+	for _, tt := range tests {
+		if got := iconURLs(icons, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("iconURLs(%d) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestGetFaviconSize(t *testing.T) {
+	var icon bytes.Buffer
+	if err := png.Encode(&icon, image.NewRGBA(image.Rect(0, 0, 64, 48))); err != nil {
+		t.Fatal(err)
+	}
+	const svg = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"></svg>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><link rel="icon" href="/icon.png" sizes="64x48"></head></html>`))
+		case "/vector":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><link rel="icon" href="/icon.svg" type="image/svg+xml"></head></html>`))
+		case "/icon.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(icon.Bytes())
+		case "/icon.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			_, _ = w.Write([]byte(svg))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	svc := newTestSvc(t, DefaultOptions())
+	size := func(n int) *int { return &n }
+	tests := []struct {
+		path          string
+		size          *int
+		contentType   string
+		width, height int
+	}{
+		{"/", nil, "image/png", 64, 48},
+		{"/", size(64), "image/png", 64, 48},
+		{"/", size(32), "image/png", 32, 24},
+		{"/", size(128), "image/png", 128, 96},
+		{"/vector", size(32), "image/svg+xml", 0, 0},
+	}
+	for _, tt := range tests {
+		contentType, data, err := svc.GetFavicon(context.Background(), routes.GetFaviconParams{Url: server.URL + tt.path, Size: tt.size})
+		if err != nil {
+			t.Errorf("%s with size %v: %v", tt.path, tt.size, err)
+			continue
+		}
+		if contentType != tt.contentType {
+			t.Errorf("%s with size %v: content type %q, want %q", tt.path, tt.size, contentType, tt.contentType)
+		}
+		if tt.width == 0 {
+			if string(data) != svg {
+				t.Errorf("%s with size %v: vector icon changed", tt.path, tt.size)
+			}
+			continue
+		}
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil || config.Width != tt.width || config.Height != tt.height {
+			t.Errorf("%s with size %v: %dx%d, %v, want %dx%d", tt.path, tt.size, config.Width, config.Height, err, tt.width, tt.height)
+		}
+	}
+
+	for _, n := range []int{0, maxIconSize + 1} {
+		_, _, err := svc.GetFavicon(context.Background(), routes.GetFaviconParams{Url: server.URL, Size: size(n)})
+		if !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("size %d: %v, want %v", n, err, ErrInvalidParameter)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package opengraphsvc
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"net/http"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+)
+
+// fetchDocument downloads url through the safe fetcher and parses it. The
+// whole page is read when the extractor looks at the body, for JSON-LD or
+// an image source. Failures are
+// returned as *fetcher.Error so handlers can report them.
+func (svc *OpenGraphSvcImpl) fetchDocument(ctx context.Context, url string) (*goquery.Document, *fetcher.Response, error) {
+	res, err := svc.fetcher.Do(ctx, &fetcher.Request{URL: url, HTML: true, FullBody: svc.extract.ReadsBody()})
+	if err != nil {
+		return nil, nil, err
+	}
+	if mediaType := contentType(res); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, res, &fetcher.Error{
+			Kind: fetcher.KindNotHTML,
+			URL:  url,
+			Err:  errors.Errorf("unexpected content type %q", mediaType),
+		}
+	}
+	// goquery assumes UTF-8
+	body, encoding := fetcher.DecodeHTML(res.Body, res.Header.Get("Content-Type"))
+	if encoding != "utf-8" {
+		svc.logger.Debugw("transcoded page", "url", url, "encoding", encoding)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, res, errors.Wrap(err, "parsing document")
+	}
+	return doc, res, nil
+}
+
+// contentType returns the media type of res, sniffing the body when the
+// server did not declare one.
+func contentType(res *fetcher.Response) string {
+	header := res.Header.Get("Content-Type")
+	if header == "" {
+		header = http.DetectContentType(res.Body)
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
@@ -2,58 +2,46 @@ package opengraphsvc
 
 import (
 	"context"
-	"net/http"
-	"strings"
 
 	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
-	"github.com/PuerkitoBio/goquery"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
 )
 
 func (svc *OpenGraphSvcImpl) GetMetadata(ctx context.Context, params routes.GetMetadataParams) (routes.Metadata, error) {
-	res, err := http.Get(params.Url)
-	if err != nil {
-		svc.logger.Debugf("error in get request", err)
-	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		svc.logger.Debugf("status code error: %d %s", res.StatusCode, res.Status)
+	if params.Profile != nil {
+		ctx = fetcher.WithProfile(ctx, *params.Profile)
 	}
-
-	// Load the HTML document
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	p, err := svc.loadPage(ctx, params.Url)
 	if err != nil {
-		svc.logger.Debugf("error in goquery", err)
+		return routes.Metadata{}, err
 	}
 
-	metaData := make(map[string]string)
-
-	// Parse Open Graph and Twitter data
-	doc.Find("meta").Each(func(i int, s *goquery.Selection) {
-		property, _ := s.Attr("property")
-		name, _ := s.Attr("name")
-		content, _ := s.Attr("content")
-
-		if strings.HasPrefix(property, "og:") {
-			metaData[property] = content
-		}
-		if strings.HasPrefix(name, "twitter:") {
-			metaData[name] = content
+	response := responseMetadata(p, params.Url, params.Jsonld != nil && *params.Jsonld)
+	if params.Probe != nil && *params.Probe {
+		probes := svc.probeImages(ctx, p)
+		response.ImageProbes = toImageProbes(probes)
+		// an image that does not load is worse than none
+		response.Image, response.ImageSource = "", nil
+		if best, ok := bestImage(probes); ok {
+			response.Image = best.url
+			response.ImageSource = optString(string(best.source))
 		}
-	})
-
-	var response routes.Metadata
-	response.Title = metaData["og:title"]
-	if response.Title == "" {
-		// get title from <title> tag
-		response.Title = doc.Find("title").Text()
-	}
-	response.Description = metaData["og:description"]
-	if response.Description == "" {
-		// get description from <meta name="description"> tag
-		response.Description = doc.Find("meta[name=description]").AttrOr("content", "")
 	}
-	response.Image = metaData["og:image"]
-	response.Url = params.Url
-
 	return response, nil
 }
+
+// responseMetadata converts the page loaded for url, optionally with its raw
+// JSON-LD objects.
+func responseMetadata(p *page, url string, jsonLD bool) routes.Metadata {
+	response := toMetadata(p.meta)
+	response.Url = url
+	response.FinalUrl = p.res.FinalURL.String()
+	response.Oembed = toOEmbed(p.oembed)
+	response.Robots = optString(string(p.res.Robots))
+	response.FetchProfile = optString(p.res.Profile)
+	if jsonLD && len(p.meta.StructuredData.Raw) > 0 {
+		raw := p.meta.StructuredData.Raw
+		response.JsonLd = &raw
+	}
+	return response
+}
@@ -1,15 +1,113 @@
 package opengraphsvc
 
 import (
+	"html/template"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
 	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/oembed"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 )
 
 type OpenGraphSvcImpl struct {
-	logger logger.Logger
+	logger    logger.Logger
+	opts      *Options
+	fetcher   *fetcher.Fetcher
+	oembed    *oembed.Registry
+	templates map[string]*template.Template
+	extract   *extractor.Options
 }
 
-func Handler(logger logger.Logger) *OpenGraphSvcImpl {
-	return &OpenGraphSvcImpl{
-		logger: logger,
+// Options - configuration for OpenGraphSvcImpl
+type Options struct {
+	TemplateDir string
+	// ImageSources is the fallback chain for the page image, see
+	// extractor.DefaultImageSources.
+	ImageSources []string
+	// JSONLD enables the page's JSON-LD as a source of structured data and
+	// of the title and description. JSON-LD is often in the <body>, so it
+	// makes the fetcher read whole pages.
+	JSONLD bool
+	// Probed images smaller than ProbeMinWidth×ProbeMinHeight, larger than
+	// ProbeMaxWidth×ProbeMaxHeight or ProbeMaxBytes, or more elongated than
+	// ProbeMaxAspectRatio either way, are invalid. Zero maximums are off.
+	ProbeMinWidth       int
+	ProbeMinHeight      int
+	ProbeMaxWidth       int
+	ProbeMaxHeight      int
+	ProbeMaxBytes       int64
+	ProbeMaxAspectRatio float64
+	// ThumbnailMaxPixels bounds the size of the images decoded for a
+	// thumbnail.
+	ThumbnailMaxPixels int
+	// FallbackProfile is the header profile a page is fetched again with
+	// when the first attempt found no title, description or image or was
+	// refused with 401 or 403. Empty, the default, never retries.
+	FallbackProfile string
+}
+
+// DefaultOptions returns Options using only the built-in templates.
+func DefaultOptions() *Options {
+	sources := make([]string, 0, len(extractor.DefaultImageSources))
+	for _, source := range extractor.DefaultImageSources {
+		sources = append(sources, string(source))
 	}
+	return &Options{
+		ImageSources:        sources,
+		JSONLD:              true,
+		ProbeMinWidth:       200,
+		ProbeMinHeight:      100,
+		ProbeMaxWidth:       4096,
+		ProbeMaxHeight:      4096,
+		ProbeMaxBytes:       8 << 20,
+		ProbeMaxAspectRatio: 4,
+		ThumbnailMaxPixels:  25_000_000,
+	}
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("openGraphOptions", pflag.ExitOnError)
+	flags.StringVar(&o.TemplateDir, "template-dir", o.TemplateDir, "directory of *.html preview templates, selected by file name")
+	flags.StringSliceVar(&o.ImageSources, "image-sources", o.ImageSources, "sources tried in order for the page image: opengraph, twitter, link, jsonld, microdata and content")
+	flags.BoolVar(&o.JSONLD, "extract-jsonld", o.JSONLD, "read the JSON-LD of pages for structured data and as a fallback for the title and description, which means reading whole pages")
+	flags.IntVar(&o.ProbeMinWidth, "probe-min-width", o.ProbeMinWidth, "minimum width of a valid probed image")
+	flags.IntVar(&o.ProbeMinHeight, "probe-min-height", o.ProbeMinHeight, "minimum height of a valid probed image")
+	flags.IntVar(&o.ProbeMaxWidth, "probe-max-width", o.ProbeMaxWidth, "maximum width of a valid probed image, 0 for no limit")
+	flags.IntVar(&o.ProbeMaxHeight, "probe-max-height", o.ProbeMaxHeight, "maximum height of a valid probed image, 0 for no limit")
+	flags.Int64Var(&o.ProbeMaxBytes, "probe-max-bytes", o.ProbeMaxBytes, "maximum size in bytes of a valid probed image, when the server reports it, 0 for no limit")
+	flags.Float64Var(&o.ProbeMaxAspectRatio, "probe-max-aspect-ratio", o.ProbeMaxAspectRatio, "maximum ratio between the long and short side of a valid probed image")
+	flags.IntVar(&o.ThumbnailMaxPixels, "thumbnail-max-pixels", o.ThumbnailMaxPixels, "maximum number of pixels of an image or icon decoded for a thumbnail")
+	flags.StringVar(&o.FallbackProfile, "fallback-profile", o.FallbackProfile, "header profile to fetch a page again with when it had no title, description or image or was refused, e.g. facebookexternalhit; empty disables the retry")
+	return flags
+}
+
+func Handler(logger logger.Logger, fetcher *fetcher.Fetcher, oembedRegistry *oembed.Registry, opts *Options) (*OpenGraphSvcImpl, error) {
+	templates, err := loadTemplates(opts.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.FallbackProfile != "" && !fetcher.HasProfile(opts.FallbackProfile) {
+		return nil, errors.Errorf("unknown fallback profile %q", opts.FallbackProfile)
+	}
+	extract := extractor.DefaultOptions()
+	extract.ImageSources = nil
+	extract.JSONLD = opts.JSONLD
+	for _, name := range opts.ImageSources {
+		source, err := extractor.ParseImageSource(name)
+		if err != nil {
+			return nil, err
+		}
+		extract.ImageSources = append(extract.ImageSources, source)
+	}
+	return &OpenGraphSvcImpl{
+		logger:    logger,
+		opts:      opts,
+		fetcher:   fetcher,
+		oembed:    oembedRegistry,
+		templates: templates,
+		extract:   extract,
+	}, nil
 }
@@ -0,0 +1,215 @@
+package opengraphsvc
+
+import (
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/oembed"
+)
+
+// toMetadata converts extracted metadata into the API response model.
+func toMetadata(m *extractor.Metadata) routes.Metadata {
+	og := m.OpenGraph
+	response := routes.Metadata{
+		Title:            m.Title,
+		Description:      m.Description,
+		Image:            m.Image,
+		ImageSource:      optString(string(m.Sources[extractor.FieldImage])),
+		CanonicalUrl:     optString(m.URL),
+		Favicon:          optString(m.Favicon),
+		SiteName:         optString(m.SiteName),
+		Type:             optString(og.Type),
+		Locale:           optString(og.Locale),
+		LocaleAlternates: optStrings(og.LocaleAlternates),
+		Article:          toArticle(og.Article),
+		Book:             toBook(og.Book),
+		Profile:          toProfile(og.Profile),
+		StructuredData:   toStructuredData(m.StructuredData),
+	}
+	if len(og.Images) > 0 {
+		images := make([]routes.OpenGraphImage, 0, len(og.Images))
+		for _, img := range og.Images {
+			images = append(images, routes.OpenGraphImage{
+				Url:       img.URL,
+				SecureUrl: optString(img.SecureURL),
+				Type:      optString(img.Type),
+				Width:     optInt(img.Width),
+				Height:    optInt(img.Height),
+				Alt:       optString(img.Alt),
+			})
+		}
+		response.Images = &images
+	}
+	if len(og.Videos) > 0 {
+		videos := make([]routes.OpenGraphVideo, 0, len(og.Videos))
+		for _, v := range og.Videos {
+			videos = append(videos, routes.OpenGraphVideo{
+				Url:       v.URL,
+				SecureUrl: optString(v.SecureURL),
+				Type:      optString(v.Type),
+				Width:     optInt(v.Width),
+				Height:    optInt(v.Height),
+			})
+		}
+		response.Videos = &videos
+	}
+	if len(og.Audios) > 0 {
+		audios := make([]routes.OpenGraphAudio, 0, len(og.Audios))
+		for _, a := range og.Audios {
+			audios = append(audios, routes.OpenGraphAudio{
+				Url:       a.URL,
+				SecureUrl: optString(a.SecureURL),
+				Type:      optString(a.Type),
+			})
+		}
+		response.Audios = &audios
+	}
+	if len(m.Icons) > 0 {
+		icons := make([]routes.Icon, 0, len(m.Icons))
+		for _, icon := range m.Icons {
+			icons = append(icons, routes.Icon{
+				Url:    icon.URL,
+				Rel:    icon.Rel,
+				Type:   optString(icon.Type),
+				Sizes:  optString(icon.Sizes),
+				Width:  optInt(icon.Width),
+				Height: optInt(icon.Height),
+				Source: string(icon.Source),
+			})
+		}
+		response.Icons = &icons
+	}
+	if len(m.InvalidURLs) > 0 {
+		invalid := make([]routes.InvalidUrl, 0, len(m.InvalidURLs))
+		for _, u := range m.InvalidURLs {
+			invalid = append(invalid, routes.InvalidUrl{
+				Field:  u.Field,
+				Value:  u.Value,
+				Reason: u.Reason,
+			})
+		}
+		response.InvalidUrls = &invalid
+	}
+	return response
+}
+
+func toOEmbed(o *oembed.Response) *routes.OEmbed {
+	if o == nil {
+		return nil
+	}
+	return &routes.OEmbed{
+		Type:            o.Type,
+		Title:           optString(o.Title),
+		Html:            optString(o.HTML),
+		Url:             optString(o.URL),
+		Width:           optInt(int(o.Width)),
+		Height:          optInt(int(o.Height)),
+		ThumbnailUrl:    optString(o.ThumbnailURL),
+		ThumbnailWidth:  optInt(int(o.ThumbnailWidth)),
+		ThumbnailHeight: optInt(int(o.ThumbnailHeight)),
+		AuthorName:      optString(o.AuthorName),
+		AuthorUrl:       optString(o.AuthorURL),
+		ProviderName:    optString(o.ProviderName),
+		ProviderUrl:     optString(o.ProviderURL),
+	}
+}
+
+func toStructuredData(sd extractor.StructuredData) *routes.StructuredData {
+	if len(sd.Raw) == 0 {
+		return nil
+	}
+	data := routes.StructuredData{
+		Types:         optStrings(sd.Types),
+		Name:          optString(sd.Name),
+		Description:   optString(sd.Description),
+		Image:         optString(sd.Image),
+		Authors:       optStrings(sd.Authors),
+		DatePublished: optString(sd.DatePublished),
+		DateModified:  optString(sd.DateModified),
+		Price:         optString(sd.Price),
+		PriceCurrency: optString(sd.PriceCurrency),
+		Availability:  optString(sd.Availability),
+		RatingCount:   optInt(sd.RatingCount),
+	}
+	if sd.RatingValue != 0 {
+		rating := float32(sd.RatingValue)
+		data.RatingValue = &rating
+	}
+	if len(sd.Breadcrumb) > 0 {
+		crumbs := make([]routes.Breadcrumb, 0, len(sd.Breadcrumb))
+		for _, c := range sd.Breadcrumb {
+			crumbs = append(crumbs, routes.Breadcrumb{Name: c.Name, Url: optString(c.URL)})
+		}
+		data.Breadcrumb = &crumbs
+	}
+	return &data
+}
+
+func toArticle(a extractor.Article) *routes.Article {
+	article := routes.Article{
+		PublishedTime:  optString(a.PublishedTime),
+		ModifiedTime:   optString(a.ModifiedTime),
+		ExpirationTime: optString(a.ExpirationTime),
+		Authors:        optStrings(a.Authors),
+		Section:        optString(a.Section),
+		Tags:           optStrings(a.Tags),
+	}
+	if article == (routes.Article{}) {
+		return nil
+	}
+	return &article
+}
+
+func toBook(b extractor.Book) *routes.Book {
+	book := routes.Book{
+		Authors:     optStrings(b.Authors),
+		Isbn:        optString(b.ISBN),
+		ReleaseDate: optString(b.ReleaseDate),
+		Tags:        optStrings(b.Tags),
+	}
+	if book == (routes.Book{}) {
+		return nil
+	}
+	return &book
+}
+
+func toProfile(p extractor.Profile) *routes.Profile {
+	profile := routes.Profile{
+		FirstName: optString(p.FirstName),
+		LastName:  optString(p.LastName),
+		Username:  optString(p.Username),
+		Gender:    optString(p.Gender),
+	}
+	if profile == (routes.Profile{}) {
+		return nil
+	}
+	return &profile
+}
+
+// optString returns nil for empty strings so they are omitted from JSON.
+func optString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+func optInt64(n int64) *int64 {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+func optStrings(s []string) *[]string {
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
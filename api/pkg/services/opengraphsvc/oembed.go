@@ -0,0 +1,47 @@
+package opengraphsvc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/oembed"
+)
+
+const oembedMaxBytes = 256 << 10
+
+// fetchOEmbed returns the oEmbed data for p, discovered from the page's
+// <link rel="alternate"> or from the provider registry. oEmbed only
+// enriches the response, so failures are logged and ignored.
+func (svc *OpenGraphSvcImpl) fetchOEmbed(ctx context.Context, p *page) *oembed.Response {
+	var endpointURL string
+	if link := p.meta.FindLinkByType("alternate", "application/json+oembed"); link != nil {
+		endpointURL = link.Href
+	} else if endpoint, ok := svc.oembed.Lookup(p.res.FinalURL.String()); ok {
+		u, err := oembed.RequestURL(endpoint, p.res.FinalURL.String())
+		if err != nil {
+			svc.logger.Debugw("invalid oembed endpoint", "endpoint", endpoint, "error", err)
+			return nil
+		}
+		endpointURL = u
+	}
+	if endpointURL == "" {
+		return nil
+	}
+
+	res, err := svc.fetcher.Do(ctx, &fetcher.Request{
+		URL:      endpointURL,
+		Header:   http.Header{"Accept": []string{"application/json"}},
+		MaxBytes: oembedMaxBytes,
+	})
+	if err != nil {
+		svc.logger.Infow("oembed fetch failed", "url", endpointURL, "error", err)
+		return nil
+	}
+	data, err := oembed.Parse(res.Body)
+	if err != nil {
+		svc.logger.Infow("invalid oembed response", "url", endpointURL, "error", err)
+		return nil
+	}
+	return data
+}
@@ -2,60 +2,56 @@ package opengraphsvc
 
 import (
 	"context"
-	"fmt"
-	"net/http"
-	"strings"
 
 	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
-	"github.com/PuerkitoBio/goquery"
 )
 
 func (svc *OpenGraphSvcImpl) OpenGraphEditor(c context.Context, params routes.OpenGraphParams) (string, error) {
-	// Get the metadata
-	metaData, err := getMetadata(params.Url, params.Title, params.Description, params.Image)
+	var name string
+	if params.Template != nil {
+		name = *params.Template
+	}
+	// fail before fetching the page
+	t, err := svc.lookupTemplate(name)
 	if err != nil {
-		svc.logger.Debugf("error in get request", err)
 		return "", err
 	}
 
-	// Generate a temporary HTML page with the metadata
-	html := generateTemporaryHTML(metaData, params.Url)
+	p, err := svc.loadPage(c, params.Url)
+	if err != nil {
+		return "", err
+	}
 
-	return html, nil
+	// Get the metadata
+	metaData := tagsWithOverrides(p, params.Title, params.Description, params.Image)
+
+	// Render the preview page with the metadata
+	return renderPreview(t, previewData{
+		Title:       metaData["og:title"],
+		Description: metaData["og:description"],
+		Image:       metaData["og:image"],
+		Tags:        metaTags(metaData),
+		RedirectURL: redirectTarget(params.Url),
+		Metadata:    responseMetadata(p, params.Url, false),
+	})
 }
 
 // Helper functions
-func getMetadata(url string, customTitle *string, customDescription *string, customImage *string) (map[string]string, error) {
-	res, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
-	}
-
-	// Load the HTML document
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		return nil, err
-	}
+func tagsWithOverrides(p *page, customTitle *string, customDescription *string, customImage *string) map[string]string {
+	extracted := p.meta
 
 	metaData := make(map[string]string)
-
-	// Parse Open Graph and Twitter data
-	doc.Find("meta").Each(func(i int, s *goquery.Selection) {
-		property, _ := s.Attr("property")
-		name, _ := s.Attr("name")
-		content, _ := s.Attr("content")
-
-		if strings.HasPrefix(property, "og:") {
-			metaData[property] = content
-		}
-		if strings.HasPrefix(name, "twitter:") {
-			metaData[name] = content
+	for _, p := range extracted.Properties {
+		if _, ok := metaData[p.Name]; !ok {
+			metaData[p.Name] = p.Content
 		}
-	})
+	}
+
+	// Fill in the resolved values so pages without og: tags still preview
+	setDefault(metaData, "og:title", extracted.Title)
+	setDefault(metaData, "og:description", extracted.Description)
+	setDefault(metaData, "og:image", extracted.Image)
+	setDefault(metaData, "og:url", extracted.URL)
 
 	// Modify the title with custom title
 	if customTitle != nil {
@@ -74,20 +70,11 @@ func getMetadata(url string, customTitle *string, customDescription *string, cus
 		metaData["twitter:image"] = *customImage
 	}
 
-	return metaData, nil
+	return metaData
 }
 
-func generateTemporaryHTML(metaData map[string]string, originalURL string) string {
-	var builder strings.Builder
-
-	builder.WriteString("<html><head>")
-	for key, value := range metaData {
-		builder.WriteString(fmt.Sprintf("<meta name=\"%s\" content=\"%s\">", key, value))
+func setDefault(metaData map[string]string, key, value string) {
+	if metaData[key] == "" && value != "" {
+		metaData[key] = value
 	}
-	builder.WriteString("</head><body>")
-	builder.WriteString("<p></p>")
-	builder.WriteString("<script>setTimeout(function() { window.location.href = '" + originalURL + "'; }, 200);</script>")
-	builder.WriteString("</body></html>")
-
-	return builder.String()
 }
@@ -0,0 +1,73 @@
+package opengraphsvc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/oembed"
+)
+
+// page is a fetched document and everything extracted from it. Both
+// endpoints build on it so they agree on every fallback.
+type page struct {
+	res    *fetcher.Response
+	meta   *extractor.Metadata
+	oembed *oembed.Response
+}
+
+// loadPage fetches url and extracts its metadata, enriching it with the
+// page's oEmbed data when available. A page that turns out empty or
+// forbidden is fetched again with the fallback profile, if one is set,
+// unless the caller picked a profile.
+func (svc *OpenGraphSvcImpl) loadPage(ctx context.Context, url string) (*page, error) {
+	p, err := svc.loadPageWith(ctx, url)
+	if svc.opts.FallbackProfile == "" || fetcher.ProfileFromContext(ctx) != "" || !needsRetry(p, err) {
+		return p, err
+	}
+	if p != nil && p.res.Profile == svc.opts.FallbackProfile {
+		return p, err
+	}
+	svc.logger.Debugw("retrying with the fallback profile", "url", url, "profile", svc.opts.FallbackProfile, "error", err)
+	retry, retryErr := svc.loadPageWith(fetcher.WithProfile(ctx, svc.opts.FallbackProfile), url)
+	if retryErr != nil || needsRetry(retry, nil) {
+		return p, err
+	}
+	return retry, nil
+}
+
+// needsRetry reports whether a page load found nothing worth keeping: no
+// title, description or image from any source, or an upstream refusal
+// that may depend on the User-Agent. A page with a <title> but no meta
+// tags is not retried.
+func needsRetry(p *page, err error) bool {
+	if err != nil {
+		fetchErr, ok := fetcher.AsError(err)
+		return ok && fetchErr.Kind == fetcher.KindUpstreamStatus &&
+			(fetchErr.StatusCode == http.StatusUnauthorized || fetchErr.StatusCode == http.StatusForbidden)
+	}
+	return p.meta.Title == "" && p.meta.Description == "" && p.meta.Image == ""
+}
+
+func (svc *OpenGraphSvcImpl) loadPageWith(ctx context.Context, url string) (*page, error) {
+	doc, res, err := svc.fetchDocument(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	p := &page{
+		res:  res,
+		meta: extractor.Extract(doc, res.FinalURL, svc.extract),
+	}
+	p.meta.AddIcons(svc.manifestIcons(ctx, p.meta))
+	p.oembed = svc.fetchOEmbed(ctx, p)
+	if p.oembed != nil {
+		if p.oembed.ThumbnailURL != "" {
+			p.oembed.ThumbnailURL = p.meta.ResolveURL(p.oembed.ThumbnailURL, "oembed:thumbnail_url", res.FinalURL)
+		}
+		p.meta.SetFallback(extractor.FieldTitle, p.oembed.Title, extractor.SourceOEmbed)
+		p.meta.SetFallback(extractor.FieldImage, p.oembed.ThumbnailURL, extractor.SourceOEmbed)
+		p.meta.SetFallback(extractor.FieldSiteName, p.oembed.ProviderName, extractor.SourceOEmbed)
+	}
+	return p, nil
+}
@@ -0,0 +1,173 @@
+package opengraphsvc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/oembed"
+)
+
+// newTestSvc returns a service whose fetcher may reach local test servers.
+func newTestSvc(t *testing.T, opts *Options) *OpenGraphSvcImpl {
+	t.Helper()
+	fetchOpts := fetcher.DefaultOptions()
+	fetchOpts.AllowCIDRs = []string{"127.0.0.1/32"}
+	f, err := fetcher.New(fetchOpts, logger.GetInstance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Handler(logger.GetInstance(), f, oembed.NewRegistry(nil), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestLoadPageReadsBodyOfLargePages(t *testing.T) {
+	// the body sources sit well past the first chunk read after </head>
+	filler := strings.Repeat("<p>Lorem ipsum dolor sit amet, consectetur adipiscing elit.</p>\n", 1200)
+	page := `<html><head><title>Large page</title></head><body>
+<header><img src="/logo.png" width="200" height="60"></header>` + filler + `
+<script type="application/ld+json">{"@type":"NewsArticle","headline":"Headline","image":"/ld.jpg"}</script>
+<main><img src="/content.jpg" width="800" height="400"></main>
+</body></html>`
+	if len(page) < 64<<10 {
+		t.Fatalf("test page is only %d bytes", len(page))
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		sources []string
+		image   string
+		source  extractor.Source
+	}{
+		{[]string{"opengraph", "jsonld", "content"}, server.URL + "/ld.jpg", extractor.SourceJSONLD},
+		{[]string{"opengraph", "content"}, server.URL + "/content.jpg", extractor.SourceContent},
+		{[]string{"opengraph", "twitter"}, "", ""},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.ImageSources = tt.sources
+		p, err := newTestSvc(t, opts).loadPage(context.Background(), server.URL+"/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.image != "" && len(p.res.Body) != len(page) {
+			t.Errorf("sources %v: read %d of %d bytes", tt.sources, len(p.res.Body), len(page))
+		}
+		if p.meta.Image != tt.image || p.meta.Sources[extractor.FieldImage] != tt.source {
+			t.Errorf("sources %v: image = %q from %q, want %q from %q",
+				tt.sources, p.meta.Image, p.meta.Sources[extractor.FieldImage], tt.image, tt.source)
+		}
+	}
+}
+
+func TestLoadPageFallbackProfile(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		crawler := strings.Contains(r.UserAgent(), "facebookexternalhit")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch {
+		case crawler:
+			_, _ = w.Write([]byte(`<html><head><meta property="og:title" content="For crawlers"></head></html>`))
+		case r.URL.Path == "/titled":
+			_, _ = w.Write([]byte(`<html><head><title>For people</title></head></html>`))
+		case r.URL.Path == "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			_, _ = w.Write([]byte(`<html><head></head><body>Loading</body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		fallback string
+		path     string
+		title    string
+		requests int
+	}{
+		// the retry is opt-in
+		{"", "/empty", "", 1},
+		{"", "/forbidden", "", 1},
+		{fetcher.ProfileFacebookExternalHit, "/empty", "For crawlers", 2},
+		{fetcher.ProfileFacebookExternalHit, "/forbidden", "For crawlers", 2},
+		// a <title> is enough to keep the page
+		{fetcher.ProfileFacebookExternalHit, "/titled", "For people", 1},
+	}
+	for _, tt := range tests {
+		mu.Lock()
+		requests = map[string]int{}
+		mu.Unlock()
+		opts := DefaultOptions()
+		opts.FallbackProfile = tt.fallback
+		p, err := newTestSvc(t, opts).loadPage(context.Background(), server.URL+tt.path)
+		title := ""
+		if err == nil {
+			title = p.meta.Title
+		}
+		mu.Lock()
+		got := requests[tt.path]
+		mu.Unlock()
+		if title != tt.title || got != tt.requests {
+			t.Errorf("fallback %q, %s: title %q after %d requests (error %v), want %q after %d",
+				tt.fallback, tt.path, title, got, err, tt.title, tt.requests)
+		}
+	}
+}
+
+func TestLoadPageReadsBodyJSONLD(t *testing.T) {
+	filler := strings.Repeat("<p>Lorem ipsum dolor sit amet, consectetur adipiscing elit.</p>\n", 1200)
+	page := `<html><head><meta charset="utf-8"></head><body>` + filler + `
+<script type="application/ld+json">{"@type":"Article","headline":"From JSON-LD","description":"Described in the body"}</script>
+</body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		jsonLD      bool
+		title       string
+		description string
+	}{
+		{true, "From JSON-LD", "Described in the body"},
+		// without JSON-LD nothing needs the body
+		{false, "", ""},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		// no image source reads the body
+		opts.ImageSources = []string{"opengraph", "twitter", "link"}
+		opts.JSONLD = tt.jsonLD
+		p, err := newTestSvc(t, opts).loadPage(context.Background(), server.URL+"/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.meta.Title != tt.title || p.meta.Description != tt.description {
+			t.Errorf("jsonld %v: title %q, description %q, want %q and %q",
+				tt.jsonLD, p.meta.Title, p.meta.Description, tt.title, tt.description)
+		}
+		if tt.jsonLD && p.meta.Sources[extractor.FieldTitle] != extractor.SourceJSONLD {
+			t.Errorf("title from %q, want jsonld", p.meta.Sources[extractor.FieldTitle])
+		}
+		if !tt.jsonLD && !p.res.Truncated {
+			t.Errorf("read the whole page without JSON-LD")
+		}
+	}
+}
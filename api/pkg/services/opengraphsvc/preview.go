@@ -0,0 +1,156 @@
+package opengraphsvc
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/pkg/errors"
+)
+
+// DefaultTemplate is the preview template used when none is requested.
+const DefaultTemplate = "default"
+
+// ErrUnknownTemplate is returned when a preview template does not exist.
+var ErrUnknownTemplate = errors.New("unknown template")
+
+//go:embed templates/*.html
+var builtinTemplates embed.FS
+
+// templateName is the pattern of template names, they are file names
+// without the .html extension.
+var templateName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// previewData is the input of every preview template. html/template
+// escapes each value for its context, including the redirect target when it
+// is used in a script.
+type previewData struct {
+	// Title, Description and Image are the values of the page with the
+	// overrides of the request applied.
+	Title       string
+	Description string
+	Image       string
+	Tags        []metaTag
+	// RedirectURL is empty when the original URL is not a web URL.
+	RedirectURL string
+	// Metadata is everything extracted from the page.
+	Metadata routes.Metadata
+}
+
+// metaTag is a <meta> element. Open Graph tags use the property attribute,
+// everything else, including Twitter cards, uses name.
+type metaTag struct {
+	Property bool
+	Name     string
+	Content  string
+}
+
+// propertyPrefixes are the namespaces crawlers read from property=.
+var propertyPrefixes = []string{"og:", "article:", "book:", "profile:", "fb:"}
+
+// loadTemplates parses the built-in templates and then those in dir, if
+// set. A template in dir replaces a built-in one of the same name.
+func loadTemplates(dir string) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template)
+	if err := parseTemplates(templates, builtinTemplates, "templates"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := parseTemplates(templates, os.DirFS(dir), "."); err != nil {
+			return nil, errors.Wrapf(err, "loading templates from %s", dir)
+		}
+	}
+	return templates, nil
+}
+
+func parseTemplates(templates map[string]*template.Template, fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.html"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".html")
+		if !templateName.MatchString(name) {
+			continue
+		}
+		t, err := template.ParseFS(fsys, file)
+		if err != nil {
+			return errors.Wrapf(err, "parsing template %s", file)
+		}
+		templates[name] = t
+	}
+	return nil
+}
+
+// lookupTemplate returns the preview template called name, or the default one
+// if name is empty.
+func (svc *OpenGraphSvcImpl) lookupTemplate(name string) (*template.Template, error) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	t, ok := svc.templates[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownTemplate, "template %q", name)
+	}
+	return t, nil
+}
+
+func renderPreview(t *template.Template, data previewData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "rendering template %q", t.Name())
+	}
+	return buf.String(), nil
+}
+
+// metaTags orders metaData with Open Graph tags first, alphabetical within
+// each group.
+func metaTags(metaData map[string]string) []metaTag {
+	names := make([]string, 0, len(metaData))
+	for name := range metaData {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := isProperty(names[i]), isProperty(names[j])
+		if pi != pj {
+			return pi
+		}
+		return names[i] < names[j]
+	})
+	tags := make([]metaTag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, metaTag{
+			Property: isProperty(name),
+			Name:     name,
+			Content:  metaData[name],
+		})
+	}
+	return tags
+}
+
+func isProperty(name string) bool {
+	for _, prefix := range propertyPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectTarget returns rawURL if browsers may safely be sent there, that
+// is an absolute http or https URL, and "" otherwise.
+func redirectTarget(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
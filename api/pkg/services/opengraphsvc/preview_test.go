@@ -0,0 +1,207 @@
+package opengraphsvc
+
+import (
+	"flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"golang.org/x/net/html"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// hostile is preview data trying to break out of every context the
+// templates use.
+var hostile = previewData{
+	Title:       `Title "><script>alert(1)</script>`,
+	Description: `Description </p><img src=x onerror=alert(1)> & more`,
+	Image:       `javascript:alert(1)`,
+	Tags: metaTags(map[string]string{
+		"og:title":            `Title "><script>alert(1)</script>`,
+		"og:image":            `javascript:alert(1)`,
+		`og:x" onload="alert`: `1`,
+		"twitter:card":        `summary_large_image`,
+		"description":         `</script><script>alert(1)</script>`,
+	}),
+	RedirectURL: redirectTarget(`https://example.com/a?q="</script><script>alert(1)</script>&b='x'#frag`),
+	Metadata:    routes.Metadata{SiteName: stringPtr(`<b>Site</b>`)},
+}
+
+func TestRenderPreviewGolden(t *testing.T) {
+	templates, err := loadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tmpl := range templates {
+		t.Run(name, func(t *testing.T) {
+			got, err := renderPreview(tmpl, hostile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "preview", name+".golden.html")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v, run the tests with -update to create it", err)
+			}
+			if got != string(want) {
+				t.Errorf("template %s rendered\n%s\nwant\n%s", name, got, want)
+			}
+			checkPreview(t, name, got, hostile)
+		})
+	}
+}
+
+func TestRedirectTarget(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://example.com/a?b=c#d", "https://example.com/a?b=c#d"},
+		{"http://example.com", "http://example.com"},
+		{"HTTPS://example.com/", "https://example.com/"},
+		{"javascript:alert(1)", ""},
+		{"JaVaScRiPt:alert(1)", ""},
+		{" javascript:alert(1)", ""},
+		{"data:text/html,<script>alert(1)</script>", ""},
+		{"vbscript:msgbox", ""},
+		{"//evil.example/", ""},
+		{"/relative", ""},
+		{"https:///no-host", ""},
+		{"http://[::1", ""},
+		{`https://example.com/"><script>`, "https://example.com/%22%3E%3Cscript%3E"},
+	}
+	for _, tt := range tests {
+		if got := redirectTarget(tt.in); got != tt.want {
+			t.Errorf("redirectTarget(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func FuzzRedirectTarget(f *testing.F) {
+	for _, seed := range []string{
+		"https://example.com/", "javascript:alert(1)", "data:text/html,x", "//x", "https://a/\"'<>",
+		"http://example.com/</script>", "\x00https://x", "https://x\n/", "java\tscript:alert(1)",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		got := redirectTarget(raw)
+		if got == "" {
+			return
+		}
+		u, err := url.Parse(got)
+		if err != nil {
+			t.Fatalf("redirectTarget(%q) = %q, which does not parse: %v", raw, got, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			t.Fatalf("redirectTarget(%q) = %q, not an absolute web URL", raw, got)
+		}
+		if again := redirectTarget(got); again != got {
+			t.Fatalf("redirectTarget(%q) = %q, but redirectTarget of that is %q", raw, got, again)
+		}
+	})
+}
+
+func FuzzRenderPreview(f *testing.F) {
+	f.Add(hostile.Title, hostile.Description, hostile.Image, "https://example.com/</script>", `x" y="`)
+	f.Add("", "", "data:image/svg+xml,<svg onload=alert(1)>", "javascript:alert(1)", "")
+	f.Add("</title><script>", "<!--", "https://example.com/i.png", `https://e.com/'-alert(1)-'`, "--></script>")
+	templates, err := loadTemplates("")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Fuzz(func(t *testing.T, title, description, image, redirect, name string) {
+		data := previewData{
+			Title:       title,
+			Description: description,
+			Image:       image,
+			Tags: metaTags(map[string]string{
+				"og:title":       title,
+				"og:description": description,
+				"og:image":       image,
+				"og:" + name:     description,
+			}),
+			RedirectURL: redirectTarget(redirect),
+			Metadata:    routes.Metadata{SiteName: &name},
+		}
+		for name, tmpl := range templates {
+			out, err := renderPreview(tmpl, data)
+			if err != nil {
+				// html/template refuses some values, e.g. invalid UTF-8 in a
+				// script, which is fine as long as nothing is served
+				continue
+			}
+			checkPreview(t, name, out, data)
+		}
+	})
+}
+
+// checkPreview fails if out, rendered by the built-in template called
+// name, has more elements than the template produces for data, event
+// handlers or URL attributes with a script scheme.
+func checkPreview(t *testing.T, name, out string, data previewData) {
+	t.Helper()
+	wantScripts, wantImages := 0, 0
+	if name == "redirect" && data.RedirectURL != "" {
+		wantScripts = 1
+	}
+	if name == DefaultTemplate && data.Image != "" {
+		wantImages = 1
+	}
+	scripts, images, metas := 0, 0, 0
+	tokenizer := html.NewTokenizer(strings.NewReader(out))
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+		switch token.Data {
+		case "script":
+			scripts++
+		case "img":
+			images++
+		case "meta":
+			metas++
+		}
+		for _, attr := range token.Attr {
+			if strings.HasPrefix(attr.Key, "on") {
+				t.Fatalf("event handler attribute %s in\n%s", attr.Key, out)
+			}
+			if attr.Key != "href" && attr.Key != "src" {
+				continue
+			}
+			value := strings.ToLower(strings.TrimSpace(attr.Val))
+			for _, scheme := range []string{"javascript:", "data:", "vbscript:"} {
+				if strings.HasPrefix(value, scheme) {
+					t.Fatalf("%s=%q in\n%s", attr.Key, attr.Val, out)
+				}
+			}
+		}
+	}
+	if scripts != wantScripts || images != wantImages {
+		t.Fatalf("rendered %d scripts and %d images, want %d and %d\n%s", scripts, images, wantScripts, wantImages, out)
+	}
+	// charset, viewport and one per tag at most
+	if metas > len(data.Tags)+2 {
+		t.Fatalf("rendered %d meta elements for %d tags\n%s", metas, len(data.Tags), out)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,219 @@
+package opengraphsvc
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/imaging"
+)
+
+const (
+	// probeBytes is enough for the headers of common formats, JPEGs with
+	// large EXIF blocks aside, which are reported as undecodable.
+	probeBytes        = 64 << 10
+	maxProbes         = 8
+	probeWorkers      = 4
+	idealAspect       = 1.91
+	reasonTooSmall    = "too_small"
+	reasonTooLarge    = "too_large"
+	reasonAspect      = "bad_aspect_ratio"
+	reasonUndecodable = "undecodable"
+)
+
+// imageProbe is what a range request revealed about a candidate image.
+type imageProbe struct {
+	url         string
+	source      extractor.Source
+	valid       bool
+	reason      string
+	contentType string
+	width       int
+	height      int
+	size        int64
+}
+
+// probeImages checks the candidate images of p and returns the probes in
+// candidate order.
+func (svc *OpenGraphSvcImpl) probeImages(ctx context.Context, p *page) []imageProbe {
+	candidates := imageCandidates(p)
+	probes := make([]imageProbe, len(candidates))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < minInt(probeWorkers, len(candidates)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				probes[i] = svc.probeImage(ctx, candidates[i])
+			}
+		}()
+	}
+	for i := range candidates {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return probes
+}
+
+// imageCandidates lists the distinct images of p in the order of the
+// image sources, followed by the oEmbed thumbnail.
+func imageCandidates(p *page) []extractor.ImageCandidate {
+	candidates := p.meta.ImageCandidates
+	if p.oembed != nil && p.oembed.ThumbnailURL != "" {
+		candidates = append(candidates[:len(candidates):len(candidates)],
+			extractor.ImageCandidate{URL: p.oembed.ThumbnailURL, Source: extractor.SourceOEmbed})
+	}
+
+	seen := make(map[string]bool)
+	var distinct []extractor.ImageCandidate
+	for _, candidate := range candidates {
+		if seen[candidate.URL] {
+			continue
+		}
+		seen[candidate.URL] = true
+		distinct = append(distinct, candidate)
+		if len(distinct) == maxProbes {
+			break
+		}
+	}
+	return distinct
+}
+
+// probeImage reads the start of imageURL to learn its type and dimensions.
+func (svc *OpenGraphSvcImpl) probeImage(ctx context.Context, candidate extractor.ImageCandidate) imageProbe {
+	imageURL := candidate.URL
+	probe := imageProbe{url: imageURL, source: candidate.Source}
+	res, err := svc.fetcher.Do(ctx, &fetcher.Request{
+		URL: imageURL,
+		Header: http.Header{
+			"Accept": []string{"image/*"},
+			"Range":  []string{"bytes=0-" + strconv.Itoa(probeBytes-1)},
+		},
+		MaxBytes: probeBytes,
+		Partial:  true,
+	})
+	if err != nil {
+		svc.logger.Debugw("image probe failed", "url", imageURL, "error", err)
+		probe.reason = string(fetcher.KindUnreachable)
+		if kind := fetchErrorKind(err); kind != "" {
+			probe.reason = string(kind)
+		}
+		return probe
+	}
+	probe.size = resourceSize(res)
+
+	contentType, ok := imageContentType(res)
+	if !ok {
+		probe.reason = string(fetcher.KindNotImage)
+		return probe
+	}
+	probe.contentType = contentType
+	if max := svc.opts.ProbeMaxBytes; max > 0 && probe.size > max {
+		probe.reason = reasonTooLarge
+		return probe
+	}
+	// SVGs have no pixel dimensions and are given the benefit of the doubt
+	if contentType != "image/svg+xml" {
+		config, _, err := imaging.DecodeConfig(res.Body)
+		if err != nil {
+			svc.logger.Debugw("image undecodable", "url", imageURL, "error", err)
+			probe.reason = reasonUndecodable
+			return probe
+		}
+		probe.width, probe.height = config.Width, config.Height
+	}
+	probe.valid, probe.reason = svc.checkDimensions(probe.width, probe.height)
+	return probe
+}
+
+// checkDimensions applies the size and aspect ratio rules. Images of
+// unknown dimensions, SVGs, pass.
+func (svc *OpenGraphSvcImpl) checkDimensions(width, height int) (bool, string) {
+	if width == 0 || height == 0 {
+		return true, ""
+	}
+	if width < svc.opts.ProbeMinWidth || height < svc.opts.ProbeMinHeight {
+		return false, reasonTooSmall
+	}
+	if (svc.opts.ProbeMaxWidth > 0 && width > svc.opts.ProbeMaxWidth) ||
+		(svc.opts.ProbeMaxHeight > 0 && height > svc.opts.ProbeMaxHeight) {
+		return false, reasonTooLarge
+	}
+	ratio := float64(width) / float64(height)
+	if max := svc.opts.ProbeMaxAspectRatio; max > 0 && (ratio > max || ratio < 1/max) {
+		return false, reasonAspect
+	}
+	return true, ""
+}
+
+// resourceSize returns the full size of a possibly partial response, or 0.
+func resourceSize(res *fetcher.Response) int64 {
+	if res.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes 0-65535/1234567
+		contentRange := res.Header.Get("Content-Range")
+		if i := strings.LastIndexByte(contentRange, '/'); i >= 0 {
+			size, _ := strconv.ParseInt(contentRange[i+1:], 10, 64)
+			return size
+		}
+		return 0
+	}
+	size, _ := strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64)
+	return size
+}
+
+// bestImage returns the valid probe closest to the card aspect ratio,
+// preferring images of known dimensions and then the declaration order.
+func bestImage(probes []imageProbe) (imageProbe, bool) {
+	var valid []imageProbe
+	for _, probe := range probes {
+		if probe.valid {
+			valid = append(valid, probe)
+		}
+	}
+	if len(valid) == 0 {
+		return imageProbe{}, false
+	}
+	sort.SliceStable(valid, func(a, b int) bool {
+		return aspectDistance(valid[a]) < aspectDistance(valid[b])
+	})
+	return valid[0], true
+}
+
+// aspectDistance is how far probe is from the ideal aspect ratio on a log
+// scale, so 2:1 and 1:2 images are penalised alike.
+func aspectDistance(probe imageProbe) float64 {
+	if probe.width == 0 || probe.height == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(math.Log(float64(probe.width) / float64(probe.height) / idealAspect))
+}
+
+// toImageProbes converts probes into the API response model.
+func toImageProbes(probes []imageProbe) *[]routes.ImageProbe {
+	if len(probes) == 0 {
+		return nil
+	}
+	response := make([]routes.ImageProbe, 0, len(probes))
+	for _, probe := range probes {
+		response = append(response, routes.ImageProbe{
+			Url:         probe.url,
+			Source:      optString(string(probe.source)),
+			Valid:       probe.valid,
+			Reason:      optString(probe.reason),
+			ContentType: optString(probe.contentType),
+			Width:       optInt(probe.width),
+			Height:      optInt(probe.height),
+			Size:        optInt64(probe.size),
+		})
+	}
+	return &response
+}
@@ -0,0 +1,146 @@
+package opengraphsvc
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/extractor"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/fetcher"
+)
+
+func TestCheckDimensions(t *testing.T) {
+	svc := &OpenGraphSvcImpl{opts: DefaultOptions()}
+	tests := []struct {
+		width, height int
+		valid         bool
+		reason        string
+	}{
+		{1200, 630, true, ""},
+		{200, 100, true, ""},
+		{4096, 4096, true, ""},
+		// unknown dimensions pass
+		{0, 0, true, ""},
+		{199, 630, false, reasonTooSmall},
+		{1200, 99, false, reasonTooSmall},
+		{5000, 2000, false, reasonTooLarge},
+		{2000, 5000, false, reasonTooLarge},
+		{2000, 400, false, reasonAspect},
+		{400, 1700, false, reasonAspect},
+	}
+	for _, tt := range tests {
+		valid, reason := svc.checkDimensions(tt.width, tt.height)
+		if valid != tt.valid || reason != tt.reason {
+			t.Errorf("checkDimensions(%d, %d) = %v, %q, want %v, %q", tt.width, tt.height, valid, reason, tt.valid, tt.reason)
+		}
+	}
+
+	// zero maximums are off
+	svc.opts.ProbeMaxWidth, svc.opts.ProbeMaxHeight, svc.opts.ProbeMaxAspectRatio = 0, 0, 0
+	if valid, reason := svc.checkDimensions(50000, 200); !valid {
+		t.Errorf("checkDimensions without maximums = %v, %q", valid, reason)
+	}
+}
+
+func TestResourceSize(t *testing.T) {
+	tests := []struct {
+		status int
+		header http.Header
+		want   int64
+	}{
+		{http.StatusPartialContent, http.Header{"Content-Range": {"bytes 0-65535/1234567"}, "Content-Length": {"65536"}}, 1234567},
+		{http.StatusPartialContent, http.Header{"Content-Range": {"bytes 0-65535/*"}}, 0},
+		{http.StatusPartialContent, http.Header{"Content-Length": {"65536"}}, 0},
+		{http.StatusOK, http.Header{"Content-Length": {"2048"}}, 2048},
+		// a server ignoring the range sends the whole image
+		{http.StatusOK, http.Header{"Content-Range": {"bytes 0-1/99"}, "Content-Length": {"4096"}}, 4096},
+		{http.StatusOK, http.Header{}, 0},
+	}
+	for _, tt := range tests {
+		res := &fetcher.Response{StatusCode: tt.status, Header: tt.header}
+		if got := resourceSize(res); got != tt.want {
+			t.Errorf("resourceSize(%d, %v) = %d, want %d", tt.status, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestBestImage(t *testing.T) {
+	probe := func(url string, valid bool, width, height int) imageProbe {
+		return imageProbe{url: url, valid: valid, width: width, height: height}
+	}
+	tests := []struct {
+		name   string
+		probes []imageProbe
+		want   string
+	}{
+		{"none", nil, ""},
+		{"all invalid", []imageProbe{probe("a", false, 1200, 630)}, ""},
+		{"closest to the card ratio", []imageProbe{probe("square", true, 600, 600), probe("card", true, 1200, 630), probe("wide", true, 1600, 400)}, "card"},
+		{"invalid skipped", []imageProbe{probe("card", false, 1200, 630), probe("square", true, 600, 600)}, "square"},
+		{"known dimensions first", []imageProbe{probe("svg", true, 0, 0), probe("tall", true, 300, 900)}, "tall"},
+		{"declaration order on ties", []imageProbe{probe("first", true, 1200, 630), probe("second", true, 1200, 630)}, "first"},
+		{"unknown dimensions as a last resort", []imageProbe{probe("small", false, 10, 10), probe("svg", true, 0, 0)}, "svg"},
+	}
+	for _, tt := range tests {
+		got, ok := bestImage(tt.probes)
+		if ok != (tt.want != "") || got.url != tt.want {
+			t.Errorf("%s: bestImage = %q, %v, want %q", tt.name, got.url, ok, tt.want)
+		}
+	}
+}
+
+func TestProbeImage(t *testing.T) {
+	var card bytes.Buffer
+	if err := png.Encode(&card, image.NewRGBA(image.Rect(0, 0, 1200, 630))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/card.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(card.Bytes())
+		case "/corrupt.png":
+			// a PNG signature followed by garbage
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0xff}, 64)...))
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Range", "bytes 0-65535/99999999")
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(card.Bytes())
+		case "/icon.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			_, _ = w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"></svg>`))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	svc := newTestSvc(t, DefaultOptions())
+	tests := []struct {
+		path   string
+		valid  bool
+		reason string
+	}{
+		{"/card.png", true, ""},
+		{"/corrupt.png", false, reasonUndecodable},
+		{"/huge.png", false, reasonTooLarge},
+		{"/icon.svg", true, ""},
+		{"/page.html", false, string(fetcher.KindNotImage)},
+		{"/missing.png", false, string(fetcher.KindUpstreamStatus)},
+	}
+	for _, tt := range tests {
+		probe := svc.probeImage(context.Background(), extractor.ImageCandidate{URL: server.URL + tt.path})
+		if probe.valid != tt.valid || probe.reason != tt.reason {
+			t.Errorf("%s: valid %v, reason %q, want %v, %q", tt.path, probe.valid, probe.reason, tt.valid, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package opengraphsvc
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/imaging"
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/thumbnail"
+	"github.com/pkg/errors"
+)
+
+const (
+	thumbnailImageMaxBytes = 5 << 20
+	thumbnailIconSize      = 96
+	// thumbnailIconAttempts bounds how many icons are tried before giving up
+	thumbnailIconAttempts = 3
+	thumbnailJPEGQuality  = 90
+	minFontSize           = 8
+	maxFontSize           = 200
+)
+
+// GetThumbnail renders a social card of params.Url. The page's image and
+// icon are optional, a card is drawn from whatever could be loaded.
+func (svc *OpenGraphSvcImpl) GetThumbnail(ctx context.Context, params routes.GetThumbnailParams) (string, []byte, error) {
+	style, err := thumbnailStyle(params)
+	if err != nil {
+		return "", nil, err
+	}
+	format := imaging.FormatPNG
+	if params.Format != nil {
+		switch *params.Format {
+		case routes.Png:
+		case routes.Jpeg:
+			format = imaging.FormatJPEG
+		case routes.Gif:
+			format = imaging.FormatGIF
+		default:
+			return "", nil, errors.Wrapf(ErrInvalidParameter, "unknown format %q", *params.Format)
+		}
+	}
+
+	p, err := svc.loadPage(ctx, params.Url)
+	if err != nil {
+		return "", nil, err
+	}
+	card := thumbnail.Card{
+		Title:       p.meta.Title,
+		Description: p.meta.Description,
+		SiteName:    p.meta.SiteName,
+	}
+	if card.SiteName == "" {
+		card.SiteName = strings.TrimPrefix(p.res.FinalURL.Hostname(), "www.")
+	}
+	if p.meta.Image != "" {
+		card.Image = svc.loadImage(ctx, p.meta.Image, thumbnailImageMaxBytes)
+	}
+	candidates := iconURLs(p.meta.Icons, thumbnailIconSize)
+	for i := 0; i < len(candidates) && i < thumbnailIconAttempts && card.Icon == nil; i++ {
+		card.Icon = svc.loadImage(ctx, candidates[i], iconMaxBytes)
+	}
+
+	img, err := thumbnail.Render(card, style)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "rendering thumbnail")
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format, thumbnailJPEGQuality); err != nil {
+		return "", nil, err
+	}
+	return imaging.ContentType(format), buf.Bytes(), nil
+}
+
+// loadImage fetches and decodes an image, returning nil if that fails.
+// Formats without a Go decoder, such as SVG and ICO, are skipped, and so
+// are images of more than Options.ThumbnailMaxPixels.
+func (svc *OpenGraphSvcImpl) loadImage(ctx context.Context, imageURL string, maxBytes int64) image.Image {
+	_, data, err := svc.fetchImage(ctx, imageURL, maxBytes)
+	if err != nil {
+		svc.logger.Debugw("image fetch failed", "url", imageURL, "error", err)
+		return nil
+	}
+	config, _, err := imaging.DecodeConfig(data)
+	if err != nil {
+		svc.logger.Debugw("image decode failed", "url", imageURL, "error", err)
+		return nil
+	}
+	// small files can declare huge dimensions
+	if int64(config.Width)*int64(config.Height) > int64(svc.opts.ThumbnailMaxPixels) {
+		svc.logger.Debugw("image too large", "url", imageURL, "width", config.Width, "height", config.Height)
+		return nil
+	}
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		svc.logger.Debugw("image decode failed", "url", imageURL, "error", err)
+		return nil
+	}
+	return img
+}
+
+// thumbnailStyle applies the style parameters to the default style.
+func thumbnailStyle(params routes.GetThumbnailParams) (thumbnail.Style, error) {
+	style := thumbnail.DefaultStyle()
+	if params.Layout != nil {
+		switch layout := thumbnail.Layout(*params.Layout); layout {
+		case thumbnail.LayoutStandard, thumbnail.LayoutHero, thumbnail.LayoutText:
+			style.Layout = layout
+		default:
+			return style, errors.Wrapf(ErrInvalidParameter, "unknown layout %q", layout)
+		}
+	}
+	for _, c := range []struct {
+		name  string
+		value *string
+		dst   *color.RGBA
+	}{
+		{"background", params.Background, &style.Background},
+		{"foreground", params.Foreground, &style.Foreground},
+		{"accent", params.Accent, &style.Accent},
+	} {
+		if c.value == nil {
+			continue
+		}
+		parsed, err := imaging.ParseColor(*c.value)
+		if err != nil {
+			return style, errors.Wrapf(ErrInvalidParameter, "%s: %v", c.name, err)
+		}
+		*c.dst = parsed
+	}
+	for _, size := range []struct {
+		name  string
+		value *int
+		dst   *float64
+	}{
+		{"titleSize", params.TitleSize, &style.TitleSize},
+		{"descriptionSize", params.DescriptionSize, &style.DescriptionSize},
+	} {
+		if size.value == nil {
+			continue
+		}
+		if *size.value < minFontSize || *size.value > maxFontSize {
+			return style, errors.Wrapf(ErrInvalidParameter, "%s must be between %d and %d", size.name, minFontSize, maxFontSize)
+		}
+		*size.dst = float64(*size.value)
+	}
+	return style, nil
+}
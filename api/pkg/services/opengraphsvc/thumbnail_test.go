@@ -0,0 +1,92 @@
+package opengraphsvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/api/pkg/routes"
+)
+
+// pngHeader returns the start of a PNG declaring width×height pixels,
+// enough for the header to decode.
+func pngHeader(width, height uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 6 // 8 bit RGBA
+	chunk := append([]byte("IHDR"), ihdr...)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	buf.Write(chunk)
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestLoadImageRejectsHugeImages(t *testing.T) {
+	encode := func(width, height int) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	small, large := encode(4, 4), encode(20, 20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		switch r.URL.Path {
+		case "/bomb.png":
+			_, _ = w.Write(pngHeader(30000, 30000))
+		case "/small.png":
+			_, _ = w.Write(small)
+		case "/large.png":
+			_, _ = w.Write(large)
+		}
+	}))
+	defer server.Close()
+
+	opts := DefaultOptions()
+	opts.ThumbnailMaxPixels = 100
+	svc := newTestSvc(t, opts)
+	for _, path := range []string{"/bomb.png", "/large.png"} {
+		if img := svc.loadImage(context.Background(), server.URL+path, thumbnailImageMaxBytes); img != nil {
+			t.Errorf("%s: decoded an image of %v", path, img.Bounds())
+		}
+	}
+	if img := svc.loadImage(context.Background(), server.URL+"/small.png", thumbnailImageMaxBytes); img == nil {
+		t.Error("small image was not loaded")
+	}
+}
+
+func TestThumbnailStyle(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	layout := func(l string) *routes.ThumbnailLayout { v := routes.ThumbnailLayout(l); return &v }
+	tests := []struct {
+		name   string
+		params routes.GetThumbnailParams
+		ok     bool
+	}{
+		{"defaults", routes.GetThumbnailParams{}, true},
+		{"layout", routes.GetThumbnailParams{Layout: layout("hero")}, true},
+		{"unknown layout", routes.GetThumbnailParams{Layout: layout("diagonal")}, false},
+		{"colours", routes.GetThumbnailParams{Background: str("#000"), Accent: str("ff000080")}, true},
+		{"bad colour", routes.GetThumbnailParams{Foreground: str("blue")}, false},
+		{"font size", routes.GetThumbnailParams{TitleSize: num(72)}, true},
+		{"font size too small", routes.GetThumbnailParams{DescriptionSize: num(minFontSize - 1)}, false},
+		{"font size too large", routes.GetThumbnailParams{TitleSize: num(maxFontSize + 1)}, false},
+	}
+	for _, tt := range tests {
+		_, err := thumbnailStyle(tt.params)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
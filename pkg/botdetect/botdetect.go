@@ -0,0 +1,97 @@
+package botdetect
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// DefaultUserAgents are User-Agent substrings of link unfurlers, social
+// crawlers and search engines, followed by generic crawler tokens that
+// match "bot" only at the end of a product name. In-app browsers are not
+// listed, except WhatsApp and Tumblr, whose unfurlers send nothing but the
+// app name and version.
+var DefaultUserAgents = []string{
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"slackbot",
+	"slack-imgproxy",
+	"discordbot",
+	"linkedinbot",
+	"whatsapp/",
+	"telegrambot",
+	"skypeuripreview",
+	"redditbot",
+	"tumblr/",
+	"vkshare",
+	"mastodon/",
+	"bluesky",
+	"cardyb",
+	"embedly",
+	"iframely",
+	"quora link preview",
+	"outbrain",
+	"applebot",
+	"googlebot",
+	"google-inspectiontool",
+	"bingbot",
+	"duckduckbot",
+	"yandex",
+	"baiduspider",
+	"bot/",
+	"bot;",
+	"crawler",
+	"spider",
+}
+
+// Options - configuration for Detector
+type Options struct {
+	UserAgents []string
+}
+
+// DefaultOptions returns Options matching DefaultUserAgents.
+func DefaultOptions() *Options {
+	return &Options{
+		UserAgents: append([]string(nil), DefaultUserAgents...),
+	}
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("botOptions", pflag.ExitOnError)
+	flags.StringSliceVar(&o.UserAgents, "bot-user-agents", o.UserAgents, "case-insensitive User-Agent substrings that identify crawlers, replaces the built-in list")
+	return flags
+}
+
+// Detector tells crawlers, which need the meta tags of a preview, from
+// people, who should be sent on to the page.
+type Detector struct {
+	patterns []string
+}
+
+// New - constructor for Detector
+func New(opts *Options) *Detector {
+	d := &Detector{}
+	for _, pattern := range opts.UserAgents {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			d.patterns = append(d.patterns, pattern)
+		}
+	}
+	return d
+}
+
+// IsBot reports whether userAgent belongs to a crawler. Requests without a
+// User-Agent are treated as crawlers, browsers always send one.
+func (d *Detector) IsBot(userAgent string) bool {
+	if strings.TrimSpace(userAgent) == "" {
+		return true
+	}
+	userAgent = strings.ToLower(userAgent)
+	for _, pattern := range d.patterns {
+		if strings.Contains(userAgent, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package botdetect
+
+import "testing"
+
+func TestIsBot(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		bot       bool
+	}{
+		{"", true},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"Mozilla/5.0 (compatible; Twitterbot/1.0)", true},
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"WhatsApp/2.23.20.0 A", true},
+		{"TelegramBot (like TwitterBot)", true},
+		{"Tumblr/14.0.835.186", true},
+		{"http.rb/5.1.1 (Mastodon/4.2.1; +https://mastodon.social/)", true},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", true},
+		{"Mozilla/5.0 (compatible; SemrushBot/7~bl; +http://www.semrush.com/bot.html)", true},
+		{"Mozilla/5.0 (compatible; MJ12bot/v1.4.8; http://mj12bot.com/)", true},
+		{"Mozilla/5.0 (compatible; Exabot;)", true},
+		{"Mozilla/5.0 (compatible; SomeCrawler 1.0)", true},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", false},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1", false},
+		{"Mozilla/5.0 (Linux; Android 10; CUBOT_X30) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36", false},
+		{"Mozilla/5.0 (Linux; Android 11; CUBOT KINGKONG 7 Build/RP1A.200720.011) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Mobile Safari/537.36", false},
+		{"Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36 Instagram 309.1.0.41.113 Android", false},
+	}
+	d := New(DefaultOptions())
+	for _, tt := range tests {
+		if got := d.IsBot(tt.userAgent); got != tt.bot {
+			t.Errorf("IsBot(%q) = %v, want %v", tt.userAgent, got, tt.bot)
+		}
+	}
+}
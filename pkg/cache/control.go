@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Control holds the Cache-Control directives relevant to a shared cache.
+type Control struct {
+	NoStore bool
+	NoCache bool
+	// MaxAge is set from s-maxage or max-age when HasMaxAge is true.
+	MaxAge    time.Duration
+	HasMaxAge bool
+}
+
+// ParseControl parses a Cache-Control header value. Unknown directives and
+// malformed ages are ignored.
+func ParseControl(header string) Control {
+	var c Control
+	sharedMaxAge := false
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			c.NoStore = true
+		case "no-cache":
+			c.NoCache = true
+		case "max-age", "s-maxage":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			// s-maxage takes precedence for shared caches
+			shared := strings.EqualFold(name, "s-maxage")
+			if sharedMaxAge && !shared {
+				continue
+			}
+			c.MaxAge, c.HasMaxAge = time.Duration(seconds)*time.Second, true
+			sharedMaxAge = sharedMaxAge || shared
+		}
+	}
+	return c
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseControl(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Control
+	}{
+		{"", Control{}},
+		{"no-store", Control{NoStore: true}},
+		{"No-Cache", Control{NoCache: true}},
+		{"public, max-age=300", Control{MaxAge: 5 * time.Minute, HasMaxAge: true}},
+		{`max-age="60"`, Control{MaxAge: time.Minute, HasMaxAge: true}},
+		{"no-cache, max-age=0", Control{NoCache: true, HasMaxAge: true}},
+		// s-maxage wins over max-age in either order
+		{"max-age=60, s-maxage=600", Control{MaxAge: 10 * time.Minute, HasMaxAge: true}},
+		{"s-maxage=600, max-age=60", Control{MaxAge: 10 * time.Minute, HasMaxAge: true}},
+		// malformed ages are ignored
+		{"max-age=abc", Control{}},
+		{"max-age=-1", Control{}},
+		{"s-maxage=x, max-age=60", Control{MaxAge: time.Minute, HasMaxAge: true}},
+		{"private, must-revalidate", Control{}},
+	}
+	for _, tt := range tests {
+		if got := ParseControl(tt.header); got != tt.want {
+			t.Errorf("ParseControl(%q) = %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package cache
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"https://example.com", "https://example.com/"},
+		{"  HTTPS://Example.COM/Path  ", "https://example.com/Path"},
+		{"http://example.com:80/", "http://example.com/"},
+		{"https://example.com:443/", "https://example.com/"},
+		{"https://example.com:8443/", "https://example.com:8443/"},
+		{"http://example.com:443/", "http://example.com:443/"},
+		{"https://example.com/#section", "https://example.com/"},
+		{"https://example.com/?b=2&a=1&a=0", "https://example.com/?a=1&a=0&b=2"},
+		{"https://[::1]:443/", "https://[::1]/"},
+		{"https://[::1]:8080/", "https://[::1]:8080/"},
+		// unparsable or relative input is kept
+		{"example.com/page", "example.com/page"},
+		{"http://[::1", "http://[::1"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeURL(tt.raw); got != tt.want {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
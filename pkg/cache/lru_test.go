@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewLRU[int](2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, time.Hour)
+	// reading a makes b the least recently used
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v", v, ok)
+	}
+	c.Set("c", 3, time.Hour)
+	if _, ok := c.Get("b"); ok {
+		t.Error("b not evicted")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("a served after its ttl")
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("Get(c) = %d, %v", v, ok)
+	}
+
+	// setting again replaces the value and the ttl
+	c.Set("c", 4, time.Second)
+	if v, _ := c.Get("c"); v != 4 {
+		t.Errorf("Get(c) after Set = %d, want 4", v)
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get("c"); ok {
+		t.Error("c served after its new ttl")
+	}
+
+	c.Set("d", 5, time.Hour)
+	c.Delete("d")
+	if _, ok := c.Get("d"); ok {
+		t.Error("d served after Delete")
+	}
+}
+
+func TestLRUNotStored(t *testing.T) {
+	c := NewLRU[int](2)
+	c.Set("zero", 1, 0)
+	c.Set("negative", 1, -time.Second)
+	if c.Len() != 0 {
+		t.Errorf("entries without a positive ttl stored")
+	}
+
+	disabled := NewLRU[int](0)
+	disabled.Set("a", 1, time.Hour)
+	if _, ok := disabled.Get("a"); ok {
+		t.Error("cache of capacity 0 stored an entry")
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Status reports how a request was served by a cache.
+type Status string
+
+const (
+	StatusHit  Status = "HIT"
+	StatusMiss Status = "MISS"
+	// StatusStale is an expired entry served while it is refreshed.
+	StatusStale Status = "STALE"
+)
+
+type statusKey struct{}
+
+// WithStatus returns a context in which a cache can record its Status for
+// the caller to read back with StatusFromContext.
+func WithStatus(ctx context.Context) context.Context {
+	var status Status
+	return context.WithValue(ctx, statusKey{}, &status)
+}
+
+// SetStatus records status in ctx if it was prepared with WithStatus.
+func SetStatus(ctx context.Context, status Status) {
+	if s, ok := ctx.Value(statusKey{}).(*Status); ok {
+		*s = status
+	}
+}
+
+// StatusFromContext returns the recorded status, or "" if none was set.
+func StatusFromContext(ctx context.Context) Status {
+	if s, ok := ctx.Value(statusKey{}).(*Status); ok {
+		return *s
+	}
+	return ""
+}
+
+// detachedContext keeps the values of its parent but is never canceled.
+type detachedContext struct {
+	context.Context
+}
+
+// Detach returns a context with the values of ctx that is never canceled,
+// for work shared between callers that must outlive the one starting it.
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"github.com/glebarez/sqlite"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Options - configuration for the database connection
+type Options struct {
+	Driver string
+	DSN    string
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("databaseOptions", pflag.ExitOnError)
+	flags.StringVar(&o.Driver, "database-driver", o.Driver, "database driver, sqlite or postgres")
+	flags.StringVar(&o.DSN, "database-dsn", o.DSN, "database DSN, e.g. a file path for sqlite; empty disables the database")
+	return flags
+}
+
+// Enabled reports whether a database has been configured.
+func (o *Options) Enabled() bool {
+	return o.DSN != ""
+}
+
+// Connection opens the configured database.
+func Connection(opts *Options) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch opts.Driver {
+	case "sqlite", "":
+		dialector = sqlite.Open(opts.DSN)
+	case "postgres":
+		dialector = postgres.Open(opts.DSN)
+	default:
+		return nil, errors.Errorf("unsupported database driver %q", opts.Driver)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:         gormlogger.Default.LogMode(gormlogger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "connecting to database")
+	}
+	return db, nil
+}
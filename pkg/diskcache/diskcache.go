@@ -0,0 +1,185 @@
+package diskcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cache stores byte blobs as files in a directory, evicting the least
+// recently used ones once their total size exceeds a limit. Access times
+// are kept in the file modification times so the order survives restarts.
+// It is safe for concurrent use within one process.
+type Cache struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	size     int64
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type entry struct {
+	name string
+	size int64
+}
+
+// New - constructor for Cache, indexing the files already in dir. Only
+// files the cache writes itself are indexed or removed, anything else in
+// dir is left alone.
+func New(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "creating cache directory")
+	}
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading cache directory")
+	}
+	type file struct {
+		entry
+		modTime time.Time
+	}
+	var files []file
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.Type().IsRegular() {
+			continue
+		}
+		name := dirEntry.Name()
+		// left over from an interrupted write
+		if isTempName(name) {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		if !isCacheName(name) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{entry{name: name, size: info.Size()}, info.ModTime()})
+	}
+	// oldest first, so the most recently used file ends up at the front
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		e := f.entry
+		c.items[e.name] = c.order.PushFront(&e)
+		c.size += e.size
+	}
+	c.evict()
+	return c, nil
+}
+
+// Get returns the blob stored under key.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	name := fileName(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[name]
+	if !ok {
+		return nil, false
+	}
+	path := filepath.Join(c.dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Set stores data under key, replacing any previous blob.
+func (c *Cache) Set(key string, data []byte) error {
+	if int64(len(data)) > c.maxBytes {
+		return nil
+	}
+	name := fileName(key)
+	path := filepath.Join(c.dir, name)
+	// write to a temporary file first so readers never see a partial blob
+	tmp, err := os.CreateTemp(c.dir, name+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "creating cache file")
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(err, "writing cache file")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[name]; ok {
+		e := elem.Value.(*entry)
+		c.size += int64(len(data)) - e.size
+		e.size = int64(len(data))
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[name] = c.order.PushFront(&entry{name: name, size: int64(len(data))})
+		c.size += int64(len(data))
+	}
+	c.evict()
+	return nil
+}
+
+// evict removes the least recently used files until the cache fits.
+func (c *Cache) evict() {
+	for c.size > c.maxBytes && c.order.Len() > 0 {
+		elem := c.order.Back()
+		_ = os.Remove(filepath.Join(c.dir, elem.Value.(*entry).name))
+		c.remove(elem)
+	}
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	e := elem.Value.(*entry)
+	c.order.Remove(elem)
+	delete(c.items, e.name)
+	c.size -= e.size
+}
+
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// isCacheName reports whether name could have been returned by fileName.
+func isCacheName(name string) bool {
+	if len(name) != 2*sha256.Size {
+		return false
+	}
+	for _, r := range name {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isTempName reports whether name is a temporary file written by Set.
+func isTempName(name string) bool {
+	base, rest, ok := strings.Cut(name, ".")
+	return ok && isCacheName(base) && strings.HasSuffix(rest, ".tmp")
+}
@@ -0,0 +1,161 @@
+package diskcache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func blob(n int) []byte {
+	return bytes.Repeat([]byte{'x'}, n)
+}
+
+func TestGetSet(t *testing.T) {
+	c, err := New(t.TempDir(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("empty cache returned a blob")
+	}
+	if err := c.Set("a", []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("a", []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := c.Get("a"); !ok || string(data) != "second" {
+		t.Fatalf("Get = %q, %v, want second", data, ok)
+	}
+	if c.size != int64(len("second")) {
+		t.Errorf("size = %d after replacing a blob, want %d", c.size, len("second"))
+	}
+	// blobs larger than the whole cache are not stored
+	if err := c.Set("b", blob(101)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("oversized blob was stored")
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, blob(10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a becomes the most recently used, so b is the one to go
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a missing before eviction")
+	}
+	if err := c.Set("d", blob(10)); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, fileName("b"))); !os.IsNotExist(err) {
+		t.Errorf("file of evicted blob still exists: %v", err)
+	}
+	if c.size != 30 {
+		t.Errorf("size = %d, want 30", c.size)
+	}
+}
+
+func TestNewRestoresOrder(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, blob(10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// spread the access times, a oldest and b newest
+	now := time.Now()
+	for i, key := range []string{"a", "c", "b"} {
+		modTime := now.Add(time.Duration(i-3) * time.Minute)
+		if err := os.Chtimes(filepath.Join(dir, fileName(key)), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// reopening with room for two evicts the least recently used
+	c, err = New(dir, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"a": false, "b": true, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestNewLeavesForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	name := fileName("a")
+	files := map[string]bool{
+		// written by the cache
+		name:              true,
+		name + ".123.tmp": false,
+		// anything else in the directory
+		"notes.txt":          true,
+		"backup.tmp":         true,
+		"ABC" + name[3:]:     true,
+		name + ".bak":        true,
+		name[:63] + "g":      true,
+		"x." + name + ".tmp": true,
+	}
+	for file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), blob(10), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the foreign files would not fit, but only the blob counts
+	c, err := New(dir, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.order.Len() != 1 || c.size != 10 {
+		t.Errorf("indexed %d files of %d bytes, want the one blob", c.order.Len(), c.size)
+	}
+	for file, keep := range files {
+		_, err := os.Stat(filepath.Join(dir, file))
+		if exists := err == nil; exists != keep {
+			t.Errorf("%s exists = %v", file, exists)
+		}
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	name := fileName("key")
+	if !isCacheName(name) {
+		t.Errorf("isCacheName(%q) = false", name)
+	}
+	for _, name := range []string{"", "abc", name[:63], name + "0", "G" + name[1:], "README"} {
+		if isCacheName(name) {
+			t.Errorf("isCacheName(%q) = true", name)
+		}
+	}
+	if !isTempName(name + ".42.tmp") {
+		t.Errorf("isTempName of a temporary file of Set = false")
+	}
+	for _, name := range []string{"x.tmp", name + ".tmp.bak", "a." + name + ".tmp"} {
+		if isTempName(name) {
+			t.Errorf("isTempName(%q) = true", name)
+		}
+	}
+}
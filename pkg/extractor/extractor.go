@@ -0,0 +1,333 @@
+package extractor
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// propertyPrefixes are the meta tag namespaces collected into Properties.
+var propertyPrefixes = []string{"og:", "twitter:", "article:", "book:", "profile:"}
+
+// Extract reads the metadata of doc. docURL is the URL the document was
+// served from; URL-valued fields are resolved against it (or the document's
+// <base href>) and it is the last fallback for the page URL. A nil opts
+// uses DefaultOptions.
+func Extract(doc *goquery.Document, docURL *url.URL, opts *Options) *Metadata {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	m := &Metadata{Sources: map[string]Source{}}
+
+	doc.Find("meta").Each(func(_ int, s *goquery.Selection) {
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+		// some sites put og: tags in name= and twitter: tags in property=
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("property", "")))
+		if name == "" {
+			name = strings.ToLower(strings.TrimSpace(s.AttrOr("name", "")))
+		}
+		if hasPropertyPrefix(name) {
+			m.Properties = append(m.Properties, Property{Name: name, Content: content})
+			return
+		}
+		switch name {
+		case "description":
+			setOnce(&m.HTML.Description, content)
+		case "keywords":
+			setOnce(&m.HTML.Keywords, content)
+		case "author":
+			setOnce(&m.HTML.Author, content)
+		case "theme-color":
+			setOnce(&m.HTML.ThemeColor, content)
+		}
+	})
+	m.HTML.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	m.HTML.Language = strings.TrimSpace(doc.Find("html").AttrOr("lang", ""))
+
+	doc.Find("link[rel]").Each(func(_ int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		if href == "" {
+			return
+		}
+		m.Links = append(m.Links, Link{
+			Rel:   strings.ToLower(strings.TrimSpace(s.AttrOr("rel", ""))),
+			Href:  href,
+			Type:  strings.TrimSpace(s.AttrOr("type", "")),
+			Sizes: strings.TrimSpace(s.AttrOr("sizes", "")),
+			Title: strings.TrimSpace(s.AttrOr("title", "")),
+		})
+	})
+
+	base := documentBase(doc, docURL)
+	m.resolveURLs(base)
+	m.parseOpenGraph()
+	m.parseTwitter()
+	if opts.readsJSONLD() {
+		m.parseJSONLD(doc, base)
+	}
+	m.parseImages(doc, base, opts)
+	m.parseIcons()
+	m.applyFallbacks(docURL, opts)
+	return m
+}
+
+func (m *Metadata) parseOpenGraph() {
+	og := &m.OpenGraph
+	for _, p := range m.Properties {
+		switch p.Name {
+		case "og:title":
+			setOnce(&og.Title, p.Content)
+		case "og:description":
+			setOnce(&og.Description, p.Content)
+		case "og:type":
+			setOnce(&og.Type, p.Content)
+		case "og:url":
+			setOnce(&og.URL, p.Content)
+		case "og:site_name":
+			setOnce(&og.SiteName, p.Content)
+		case "og:locale":
+			setOnce(&og.Locale, p.Content)
+		case "og:locale:alternate":
+			appendNonEmpty(&og.LocaleAlternates, p.Content)
+		case "og:image", "og:image:url":
+			if n := len(og.Images); n == 0 || startsMedia(p, "og:image", og.Images[n-1].URL) {
+				og.Images = append(og.Images, Image{URL: p.Content})
+			}
+		case "og:video", "og:video:url":
+			if n := len(og.Videos); n == 0 || startsMedia(p, "og:video", og.Videos[n-1].URL) {
+				og.Videos = append(og.Videos, Video{URL: p.Content})
+			}
+		case "og:audio", "og:audio:url":
+			if n := len(og.Audios); n == 0 || startsMedia(p, "og:audio", og.Audios[n-1].URL) {
+				og.Audios = append(og.Audios, Audio{URL: p.Content})
+			}
+		default:
+			switch {
+			case strings.HasPrefix(p.Name, "og:image:") && len(og.Images) > 0:
+				og.Images[len(og.Images)-1].setProperty(strings.TrimPrefix(p.Name, "og:image:"), p.Content)
+			case strings.HasPrefix(p.Name, "og:video:") && len(og.Videos) > 0:
+				og.Videos[len(og.Videos)-1].setProperty(strings.TrimPrefix(p.Name, "og:video:"), p.Content)
+			case strings.HasPrefix(p.Name, "og:audio:") && len(og.Audios) > 0:
+				og.Audios[len(og.Audios)-1].setProperty(strings.TrimPrefix(p.Name, "og:audio:"), p.Content)
+			case strings.HasPrefix(p.Name, "article:"):
+				og.Article.setProperty(strings.TrimPrefix(p.Name, "article:"), p.Content)
+			case strings.HasPrefix(p.Name, "book:"):
+				og.Book.setProperty(strings.TrimPrefix(p.Name, "book:"), p.Content)
+			case strings.HasPrefix(p.Name, "profile:"):
+				og.Profile.setProperty(strings.TrimPrefix(p.Name, "profile:"), p.Content)
+			}
+		}
+	}
+}
+
+// startsMedia reports whether p begins a new og:image, og:video or
+// og:audio after the one at currentURL. The bare property always does; the
+// :url alias only does when it names something else.
+func startsMedia(p Property, name, currentURL string) bool {
+	return p.Name == name || currentURL != p.Content
+}
+
+func (img *Image) setProperty(name, value string) {
+	switch name {
+	case "secure_url":
+		setOnce(&img.SecureURL, value)
+	case "type":
+		setOnce(&img.Type, value)
+	case "width":
+		img.Width = parseDimension(value)
+	case "height":
+		img.Height = parseDimension(value)
+	case "alt":
+		setOnce(&img.Alt, value)
+	}
+}
+
+func (v *Video) setProperty(name, value string) {
+	switch name {
+	case "secure_url":
+		setOnce(&v.SecureURL, value)
+	case "type":
+		setOnce(&v.Type, value)
+	case "width":
+		v.Width = parseDimension(value)
+	case "height":
+		v.Height = parseDimension(value)
+	}
+}
+
+func (a *Audio) setProperty(name, value string) {
+	switch name {
+	case "secure_url":
+		setOnce(&a.SecureURL, value)
+	case "type":
+		setOnce(&a.Type, value)
+	}
+}
+
+func (a *Article) setProperty(name, value string) {
+	switch name {
+	case "published_time":
+		setOnce(&a.PublishedTime, value)
+	case "modified_time":
+		setOnce(&a.ModifiedTime, value)
+	case "expiration_time":
+		setOnce(&a.ExpirationTime, value)
+	case "author":
+		appendNonEmpty(&a.Authors, value)
+	case "section":
+		setOnce(&a.Section, value)
+	case "tag":
+		appendNonEmpty(&a.Tags, value)
+	}
+}
+
+func (b *Book) setProperty(name, value string) {
+	switch name {
+	case "author":
+		appendNonEmpty(&b.Authors, value)
+	case "isbn":
+		setOnce(&b.ISBN, value)
+	case "release_date":
+		setOnce(&b.ReleaseDate, value)
+	case "tag":
+		appendNonEmpty(&b.Tags, value)
+	}
+}
+
+func (p *Profile) setProperty(name, value string) {
+	switch name {
+	case "first_name":
+		setOnce(&p.FirstName, value)
+	case "last_name":
+		setOnce(&p.LastName, value)
+	case "username":
+		setOnce(&p.Username, value)
+	case "gender":
+		setOnce(&p.Gender, value)
+	}
+}
+
+func (m *Metadata) parseTwitter() {
+	tw := &m.Twitter
+	for _, p := range m.Properties {
+		switch p.Name {
+		case "twitter:card":
+			setOnce(&tw.Card, p.Content)
+		case "twitter:site":
+			setOnce(&tw.Site, p.Content)
+		case "twitter:creator":
+			setOnce(&tw.Creator, p.Content)
+		case "twitter:title":
+			setOnce(&tw.Title, p.Content)
+		case "twitter:description":
+			setOnce(&tw.Description, p.Content)
+		case "twitter:image", "twitter:image:src":
+			setOnce(&tw.Image, p.Content)
+		case "twitter:image:alt":
+			setOnce(&tw.ImageAlt, p.Content)
+		}
+	}
+}
+
+// applyFallbacks applies the fallback rules shared by every endpoint.
+func (m *Metadata) applyFallbacks(docURL *url.URL, opts *Options) {
+	var canonical string
+	if link := m.FindLink("canonical"); link != nil {
+		canonical = link.Href
+	}
+	var favicon string
+	if len(m.Icons) > 0 {
+		favicon = m.Icons[0].URL
+	}
+	var documentURL string
+	if docURL != nil {
+		documentURL = docURL.String()
+	}
+
+	m.Title = m.pick(FieldTitle,
+		candidate{m.OpenGraph.Title, SourceOpenGraph},
+		candidate{m.Twitter.Title, SourceTwitter},
+		candidate{m.StructuredData.Name, SourceJSONLD},
+		candidate{m.HTML.Title, SourceHTML},
+	)
+	m.Description = m.pick(FieldDescription,
+		candidate{m.OpenGraph.Description, SourceOpenGraph},
+		candidate{m.Twitter.Description, SourceTwitter},
+		candidate{m.StructuredData.Description, SourceJSONLD},
+		candidate{m.HTML.Description, SourceHTML},
+	)
+	m.ImageCandidates = m.imageCandidates(opts.ImageSources)
+	if len(m.ImageCandidates) > 0 {
+		m.Image = m.pick(FieldImage, candidate{m.ImageCandidates[0].URL, m.ImageCandidates[0].Source})
+	}
+	m.URL = m.pick(FieldURL,
+		candidate{m.OpenGraph.URL, SourceOpenGraph},
+		candidate{canonical, SourceLink},
+		candidate{documentURL, SourceDocument},
+	)
+	m.SiteName = m.pick(FieldSiteName,
+		candidate{m.OpenGraph.SiteName, SourceOpenGraph},
+	)
+	m.Favicon = m.pick(FieldFavicon,
+		candidate{favicon, SourceLink},
+	)
+}
+
+type candidate struct {
+	value  string
+	source Source
+}
+
+// pick returns the first non-empty candidate and records its source.
+func (m *Metadata) pick(field string, candidates ...candidate) string {
+	for _, c := range candidates {
+		if c.value != "" {
+			m.Sources[field] = c.source
+			return c.value
+		}
+	}
+	return ""
+}
+
+func hasPropertyPrefix(name string) bool {
+	for _, prefix := range propertyPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRel(rels, rel string) bool {
+	for _, r := range strings.Fields(rels) {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
+
+func setOnce(dst *string, value string) {
+	if *dst == "" {
+		*dst = value
+	}
+}
+
+func appendNonEmpty(dst *[]string, value string) {
+	if value != "" {
+		*dst = append(*dst, value)
+	}
+}
+
+func parseDimension(value string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "px"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,303 @@
+package extractor
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testPageURL = "https://example.com/posts/1"
+
+// extract parses page as served from testPageURL.
+func extract(t *testing.T, page string, opts *Options) *Metadata {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	docURL, _ := url.Parse(testPageURL)
+	return Extract(doc, docURL, opts)
+}
+
+func TestExtractResolvedFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    string
+		want    map[string]string
+		sources map[string]Source
+	}{
+		{
+			name: "open graph first",
+			head: `<title>HTML title</title>
+<meta name="description" content="HTML description">
+<meta name="twitter:title" content="Twitter title">
+<meta property="og:title" content="OG title">
+<meta property="og:description" content="OG description">
+<meta property="og:url" content="/posts/1?ref=og">
+<meta property="og:site_name" content="Example">`,
+			want: map[string]string{
+				FieldTitle:       "OG title",
+				FieldDescription: "OG description",
+				FieldURL:         "https://example.com/posts/1?ref=og",
+				FieldSiteName:    "Example",
+			},
+			sources: map[string]Source{
+				FieldTitle:       SourceOpenGraph,
+				FieldDescription: SourceOpenGraph,
+				FieldURL:         SourceOpenGraph,
+				FieldSiteName:    SourceOpenGraph,
+			},
+		},
+		{
+			name: "twitter before html",
+			head: `<title>HTML title</title>
+<meta name="description" content="HTML description">
+<meta name="twitter:title" content="Twitter title">
+<link rel="canonical" href="https://example.com/canonical">`,
+			want: map[string]string{
+				FieldTitle:       "Twitter title",
+				FieldDescription: "HTML description",
+				FieldURL:         "https://example.com/canonical",
+			},
+			sources: map[string]Source{
+				FieldTitle:       SourceTwitter,
+				FieldDescription: SourceHTML,
+				FieldURL:         SourceLink,
+			},
+		},
+		{
+			name: "tags swapped between property and name",
+			head: `<meta name="og:title" content="OG in name">
+<meta property="twitter:description" content="Twitter in property">`,
+			want: map[string]string{
+				FieldTitle:       "OG in name",
+				FieldDescription: "Twitter in property",
+				FieldURL:         testPageURL,
+			},
+			sources: map[string]Source{
+				FieldTitle:       SourceOpenGraph,
+				FieldDescription: SourceTwitter,
+				FieldURL:         SourceDocument,
+			},
+		},
+		{
+			name: "empty values skipped",
+			head: `<title>  Spaced title  </title>
+<meta property="og:title" content="  ">`,
+			want: map[string]string{
+				FieldTitle: "Spaced title",
+				FieldURL:   testPageURL,
+			},
+			sources: map[string]Source{
+				FieldTitle: SourceHTML,
+				FieldURL:   SourceDocument,
+			},
+		},
+	}
+	for _, tt := range tests {
+		m := extract(t, "<html><head>"+tt.head+"</head><body></body></html>", nil)
+		got := map[string]string{
+			FieldTitle:       m.Title,
+			FieldDescription: m.Description,
+			FieldURL:         m.URL,
+			FieldSiteName:    m.SiteName,
+		}
+		for field, value := range got {
+			if value != tt.want[field] {
+				t.Errorf("%s: %s = %q, want %q", tt.name, field, value, tt.want[field])
+			}
+		}
+		if !reflect.DeepEqual(m.Sources, tt.sources) {
+			t.Errorf("%s: sources = %v, want %v", tt.name, m.Sources, tt.sources)
+		}
+	}
+}
+
+func TestExtractOpenGraphMedia(t *testing.T) {
+	tests := []struct {
+		name   string
+		head   string
+		images []Image
+		videos []Video
+		audios []Audio
+	}{
+		{
+			name: "structured properties follow their image",
+			head: `<meta property="og:image" content="https://cdn.example.com/a.png">
+<meta property="og:image:secure_url" content="https://secure.example.com/a.png">
+<meta property="og:image:width" content="1200">
+<meta property="og:image:height" content="630">
+<meta property="og:image:alt" content="First">
+<meta property="og:image" content="/b.jpg">
+<meta property="og:image:type" content="image/jpeg">
+<meta property="og:image:width" content="600px">
+<meta property="og:image" content="https://cdn.example.com/c.gif">`,
+			images: []Image{
+				{URL: "https://cdn.example.com/a.png", SecureURL: "https://secure.example.com/a.png", Width: 1200, Height: 630, Alt: "First"},
+				{URL: "https://example.com/b.jpg", Type: "image/jpeg", Width: 600},
+				{URL: "https://cdn.example.com/c.gif"},
+			},
+		},
+		{
+			name: "url alias of the current image",
+			head: `<meta property="og:image" content="https://cdn.example.com/a.png">
+<meta property="og:image:url" content="https://cdn.example.com/a.png">
+<meta property="og:image:width" content="800">
+<meta property="og:image:url" content="https://cdn.example.com/b.png">
+<meta property="og:image:height" content="400">`,
+			images: []Image{
+				{URL: "https://cdn.example.com/a.png", Width: 800},
+				{URL: "https://cdn.example.com/b.png", Height: 400},
+			},
+		},
+		{
+			name: "properties before any image ignored",
+			head: `<meta property="og:image:width" content="100">
+<meta property="og:image" content="https://cdn.example.com/a.png">`,
+			images: []Image{{URL: "https://cdn.example.com/a.png"}},
+		},
+		{
+			name: "video and audio",
+			head: `<meta property="og:video" content="https://example.com/v.mp4">
+<meta property="og:video:secure_url" content="https://secure.example.com/v.mp4">
+<meta property="og:video:type" content="video/mp4">
+<meta property="og:video:width" content="1280">
+<meta property="og:video:height" content="720">
+<meta property="og:video" content="https://example.com/v.webm">
+<meta property="og:video:type" content="video/webm">
+<meta property="og:audio" content="https://example.com/a.mp3">
+<meta property="og:audio:secure_url" content="https://secure.example.com/a.mp3">
+<meta property="og:audio:type" content="audio/mpeg">`,
+			videos: []Video{
+				{URL: "https://example.com/v.mp4", SecureURL: "https://secure.example.com/v.mp4", Type: "video/mp4", Width: 1280, Height: 720},
+				{URL: "https://example.com/v.webm", Type: "video/webm"},
+			},
+			audios: []Audio{
+				{URL: "https://example.com/a.mp3", SecureURL: "https://secure.example.com/a.mp3", Type: "audio/mpeg"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		og := extract(t, "<html><head>"+tt.head+"</head></html>", nil).OpenGraph
+		if !reflect.DeepEqual(og.Images, tt.images) {
+			t.Errorf("%s: images = %+v, want %+v", tt.name, og.Images, tt.images)
+		}
+		if !reflect.DeepEqual(og.Videos, tt.videos) {
+			t.Errorf("%s: videos = %+v, want %+v", tt.name, og.Videos, tt.videos)
+		}
+		if !reflect.DeepEqual(og.Audios, tt.audios) {
+			t.Errorf("%s: audios = %+v, want %+v", tt.name, og.Audios, tt.audios)
+		}
+	}
+}
+
+func TestExtractObjectTypes(t *testing.T) {
+	const head = `<meta property="og:type" content="article">
+<meta property="article:published_time" content="2024-05-01T10:00:00Z">
+<meta property="article:modified_time" content="2024-05-02T10:00:00Z">
+<meta property="article:expiration_time" content="2025-05-01T10:00:00Z">
+<meta property="article:author" content="https://example.com/alice">
+<meta property="article:author" content="https://example.com/bob">
+<meta property="article:section" content="Technology">
+<meta property="article:section" content="Ignored">
+<meta property="article:tag" content="go">
+<meta property="article:tag" content="html">
+<meta property="book:author" content="https://example.com/carol">
+<meta property="book:isbn" content="978-3-16-148410-0">
+<meta property="book:release_date" content="2020-01-01">
+<meta property="book:tag" content="fiction">
+<meta property="profile:first_name" content="Alice">
+<meta property="profile:last_name" content="Liddell">
+<meta property="profile:username" content="alice">
+<meta property="profile:gender" content="female">`
+	og := extract(t, "<html><head>"+head+"</head></html>", nil).OpenGraph
+
+	wantArticle := Article{
+		PublishedTime:  "2024-05-01T10:00:00Z",
+		ModifiedTime:   "2024-05-02T10:00:00Z",
+		ExpirationTime: "2025-05-01T10:00:00Z",
+		Authors:        []string{"https://example.com/alice", "https://example.com/bob"},
+		Section:        "Technology",
+		Tags:           []string{"go", "html"},
+	}
+	wantBook := Book{
+		Authors:     []string{"https://example.com/carol"},
+		ISBN:        "978-3-16-148410-0",
+		ReleaseDate: "2020-01-01",
+		Tags:        []string{"fiction"},
+	}
+	wantProfile := Profile{FirstName: "Alice", LastName: "Liddell", Username: "alice", Gender: "female"}
+	if og.Type != "article" {
+		t.Errorf("type = %q", og.Type)
+	}
+	if !reflect.DeepEqual(og.Article, wantArticle) {
+		t.Errorf("article = %+v, want %+v", og.Article, wantArticle)
+	}
+	if !reflect.DeepEqual(og.Book, wantBook) {
+		t.Errorf("book = %+v, want %+v", og.Book, wantBook)
+	}
+	if !reflect.DeepEqual(og.Profile, wantProfile) {
+		t.Errorf("profile = %+v, want %+v", og.Profile, wantProfile)
+	}
+}
+
+func TestExtractImageFallbacks(t *testing.T) {
+	const (
+		imageSrc  = `<link rel="image_src" href="/image-src.png">`
+		microdata = `<div itemscope><meta itemprop="image" content="/microdata.png"></div>`
+		content   = `<main><img src="/content.jpg" width="800" height="400"></main>`
+	)
+	tests := []struct {
+		name    string
+		head    string
+		body    string
+		sources []Source
+		image   string
+		source  Source
+	}{
+		{"declared image wins", `<meta property="og:image" content="/og.png">` + imageSrc, microdata + content, nil, "https://example.com/og.png", SourceOpenGraph},
+		{"twitter image", `<meta name="twitter:image" content="/tw.png">` + imageSrc, "", nil, "https://example.com/tw.png", SourceTwitter},
+		{"image_src link", imageSrc, microdata + content, nil, "https://example.com/image-src.png", SourceLink},
+		{"microdata", "", microdata + content, nil, "https://example.com/microdata.png", SourceMicrodata},
+		{"content image", "", content, nil, "https://example.com/content.jpg", SourceContent},
+		{"configured order", imageSrc, microdata + content, []Source{SourceContent, SourceLink}, "https://example.com/content.jpg", SourceContent},
+		{"source left out", imageSrc, "", []Source{SourceOpenGraph, SourceMicrodata}, "", ""},
+		{"small images skipped", "", `<main><img src="/small.jpg" width="100" height="100"></main>`, nil, "", ""},
+		{"images without dimensions skipped", "", `<main><img src="/unknown.jpg"></main>`, nil, "", ""},
+		{"decorative images skipped", "", `<main><img src="/site-logo.png" width="800" height="400"><img class="ad banner" src="/x.jpg" width="800" height="400"></main>`, nil, "", ""},
+		{"boilerplate skipped", "", `<body><header><img src="/header.jpg" width="800" height="400"></header><p><img src="/post.jpg" width="800" height="400"></p></body>`, nil, "https://example.com/post.jpg", SourceContent},
+		{"main content first", "", `<body><img src="/outside.jpg" width="800" height="400"><article><img src="/inside.jpg" width="800" height="400"></article></body>`, nil, "https://example.com/inside.jpg", SourceContent},
+		{"lazy loaded", "", `<main><img src="data:image/gif;base64,R0lGOD" data-src="/lazy.jpg" width="800" height="400"></main>`, nil, "https://example.com/lazy.jpg", SourceContent},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		if tt.sources != nil {
+			opts.ImageSources = tt.sources
+		}
+		m := extract(t, "<html><head>"+tt.head+"</head><body>"+tt.body+"</body></html>", opts)
+		if m.Image != tt.image || m.Sources[FieldImage] != tt.source {
+			t.Errorf("%s: image = %q from %q, want %q from %q", tt.name, m.Image, m.Sources[FieldImage], tt.image, tt.source)
+		}
+	}
+}
+
+func TestExtractImageCandidates(t *testing.T) {
+	const page = `<html><head>
+<meta property="og:image" content="https://cdn.example.com/a.png">
+<meta property="og:image:secure_url" content="https://secure.example.com/a.png">
+<meta name="twitter:image" content="https://cdn.example.com/a.png">
+<link rel="image_src" href="/b.png">
+</head><body><main><img src="/b.png" width="800" height="400"><img src="/c.png" width="800" height="400"></main></body></html>`
+	want := []ImageCandidate{
+		{"https://cdn.example.com/a.png", SourceOpenGraph},
+		{"https://secure.example.com/a.png", SourceOpenGraph},
+		{"https://example.com/b.png", SourceLink},
+		{"https://example.com/c.png", SourceContent},
+	}
+	if got := extract(t, page, nil).ImageCandidates; !reflect.DeepEqual(got, want) {
+		t.Errorf("ImageCandidates = %+v, want %+v", got, want)
+	}
+}
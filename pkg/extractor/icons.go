@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Icon is a site icon declared by a <link> element or a web app manifest.
+type Icon struct {
+	URL   string
+	Rel   string
+	Type  string
+	Sizes string
+	// Width and Height are the largest size listed in Sizes, if any.
+	Width  int
+	Height int
+	Source Source
+}
+
+// SourceManifest marks icons read from a web app manifest.
+const SourceManifest Source = "manifest"
+
+// iconRels are the link relations that declare site icons.
+var iconRels = []string{"icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon"}
+
+// NewIcon returns an icon with its dimensions parsed from sizes.
+func NewIcon(url, rel, typ, sizes string, source Source) Icon {
+	icon := Icon{URL: url, Rel: rel, Type: typ, Sizes: sizes, Source: source}
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		w, h, ok := strings.Cut(size, "x")
+		if !ok {
+			continue
+		}
+		width, errW := strconv.Atoi(w)
+		height, errH := strconv.Atoi(h)
+		if errW == nil && errH == nil && width > icon.Width {
+			icon.Width, icon.Height = width, height
+		}
+	}
+	return icon
+}
+
+// Scalable reports whether the icon is a vector image usable at any size.
+func (i Icon) Scalable() bool {
+	return strings.Contains(strings.ToLower(i.Sizes), "any") ||
+		strings.EqualFold(i.Type, "image/svg+xml") ||
+		strings.HasSuffix(strings.ToLower(i.URL), ".svg")
+}
+
+// EffectiveSize is the size used to rank icons. Undeclared sizes are
+// guessed from the icon's rel.
+func (i Icon) EffectiveSize() int {
+	switch {
+	case i.Width > 0:
+		return i.Width
+	case i.Scalable():
+		return 512
+	case strings.HasPrefix(i.Rel, "apple-touch-icon"):
+		return 180
+	}
+	return 32
+}
+
+func (m *Metadata) parseIcons() {
+	for _, link := range m.Links {
+		for _, rel := range iconRels {
+			if hasRel(link.Rel, rel) {
+				m.Icons = append(m.Icons, NewIcon(link.Href, rel, link.Type, link.Sizes, SourceLink))
+				break
+			}
+		}
+	}
+	RankIcons(m.Icons)
+}
+
+// AddIcons adds icons found outside the document, e.g. in its manifest,
+// and re-ranks the list. Favicon is updated to the best icon.
+func (m *Metadata) AddIcons(icons []Icon) {
+	for _, icon := range icons {
+		if !m.hasIcon(icon.URL) {
+			m.Icons = append(m.Icons, icon)
+		}
+	}
+	RankIcons(m.Icons)
+	if len(m.Icons) > 0 {
+		m.Favicon = m.Icons[0].URL
+		m.Sources[FieldFavicon] = m.Icons[0].Source
+	}
+}
+
+func (m *Metadata) hasIcon(url string) bool {
+	for _, icon := range m.Icons {
+		if icon.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// RankIcons sorts icons best first: larger and scalable icons rank higher,
+// monochrome mask icons always rank last.
+func RankIcons(icons []Icon) {
+	sort.SliceStable(icons, func(a, b int) bool {
+		maskA, maskB := icons[a].Rel == "mask-icon", icons[b].Rel == "mask-icon"
+		if maskA != maskB {
+			return maskB
+		}
+		return icons[a].EffectiveSize() > icons[b].EffectiveSize()
+	})
+}
@@ -0,0 +1,75 @@
+package extractor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewIcon(t *testing.T) {
+	tests := []struct {
+		url, rel, typ, sizes string
+		width, height, size  int
+		scalable             bool
+	}{
+		{"/a.png", "icon", "image/png", "16x16 32x32", 32, 32, 32, false},
+		{"/a.png", "icon", "", "48X48 bogus 24x24", 48, 48, 48, false},
+		{"/a.svg", "icon", "", "", 0, 0, 512, true},
+		{"/a", "icon", "image/svg+xml", "", 0, 0, 512, true},
+		{"/a.png", "icon", "", "any", 0, 0, 512, true},
+		{"/a.png", "apple-touch-icon", "", "", 0, 0, 180, false},
+		{"/a.png", "apple-touch-icon-precomposed", "", "", 0, 0, 180, false},
+		{"/favicon.ico", "icon", "", "", 0, 0, 32, false},
+	}
+	for _, tt := range tests {
+		icon := NewIcon(tt.url, tt.rel, tt.typ, tt.sizes, SourceLink)
+		if icon.Width != tt.width || icon.Height != tt.height || icon.EffectiveSize() != tt.size || icon.Scalable() != tt.scalable {
+			t.Errorf("NewIcon(%q, %q, %q, %q) = %dx%d, size %d, scalable %v, want %dx%d, size %d, scalable %v",
+				tt.url, tt.rel, tt.typ, tt.sizes, icon.Width, icon.Height, icon.EffectiveSize(), icon.Scalable(),
+				tt.width, tt.height, tt.size, tt.scalable)
+		}
+	}
+}
+
+func TestExtractIcons(t *testing.T) {
+	const page = `<html><head>
+<link rel="mask-icon" href="/mask.svg">
+<link rel="shortcut icon" href="/favicon.ico">
+<link rel="icon" href="/192.png" sizes="192x192">
+<link rel="apple-touch-icon" href="/apple.png">
+<link rel="icon" href="/logo.svg" type="image/svg+xml">
+<link rel="icon" href="/32.png" sizes="32x32">
+<link rel="stylesheet" href="/style.css">
+</head></html>`
+	m := extract(t, page, nil)
+	var urls []string
+	for _, icon := range m.Icons {
+		urls = append(urls, icon.URL)
+	}
+	// larger first, ties in document order, mask icons last
+	want := []string{
+		"https://example.com/logo.svg",
+		"https://example.com/192.png",
+		"https://example.com/apple.png",
+		"https://example.com/favicon.ico",
+		"https://example.com/32.png",
+		"https://example.com/mask.svg",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("icons = %v, want %v", urls, want)
+	}
+	if m.Favicon != want[0] || m.Sources[FieldFavicon] != SourceLink {
+		t.Errorf("favicon = %q from %q", m.Favicon, m.Sources[FieldFavicon])
+	}
+
+	m.AddIcons([]Icon{
+		NewIcon("https://example.com/512.png", "manifest", "image/png", "512x512", SourceManifest),
+		NewIcon("https://example.com/192.png", "manifest", "image/png", "192x192", SourceManifest),
+	})
+	if len(m.Icons) != len(want)+1 {
+		t.Errorf("%d icons after adding the manifest, duplicates kept", len(m.Icons))
+	}
+	// the manifest icon ties with the scalable one and comes after it
+	if m.Favicon != "https://example.com/logo.svg" || m.Icons[1].URL != "https://example.com/512.png" {
+		t.Errorf("favicon = %q, second icon %q", m.Favicon, m.Icons[1].URL)
+	}
+}
@@ -0,0 +1,207 @@
+package extractor
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+)
+
+// DefaultImageSources is the fallback chain for the page image: the
+// declared preview images first, then the guesses.
+var DefaultImageSources = []Source{
+	SourceOpenGraph,
+	SourceTwitter,
+	SourceLink,
+	SourceJSONLD,
+	SourceMicrodata,
+	SourceContent,
+}
+
+// Options - configuration for Extract
+type Options struct {
+	// ImageSources are tried in order for the page image.
+	ImageSources []Source
+	// JSONLD enables reading the page's JSON-LD blocks into StructuredData,
+	// which also backs the title and description. They may sit anywhere in
+	// the document. The jsonld image source reads them regardless.
+	JSONLD bool
+	// Content images smaller than MinContentImageWidth×MinContentImageHeight
+	// are ignored.
+	MinContentImageWidth  int
+	MinContentImageHeight int
+}
+
+// DefaultOptions returns Options using DefaultImageSources.
+func DefaultOptions() *Options {
+	return &Options{
+		ImageSources:          DefaultImageSources,
+		JSONLD:                true,
+		MinContentImageWidth:  300,
+		MinContentImageHeight: 150,
+	}
+}
+
+// ReadsBody reports whether extraction looks beyond the document head, for
+// JSON-LD or for an image source, so the whole page has to be fetched.
+func (o *Options) ReadsBody() bool {
+	if o.JSONLD {
+		return true
+	}
+	for _, source := range o.ImageSources {
+		switch source {
+		case SourceJSONLD, SourceMicrodata, SourceContent:
+			return true
+		}
+	}
+	return false
+}
+
+// readsJSONLD reports whether the JSON-LD blocks are parsed.
+func (o *Options) readsJSONLD() bool {
+	if o.JSONLD {
+		return true
+	}
+	for _, source := range o.ImageSources {
+		if source == SourceJSONLD {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseImageSource returns the image source called name.
+func ParseImageSource(name string) (Source, error) {
+	source := Source(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range DefaultImageSources {
+		if source == known {
+			return source, nil
+		}
+	}
+	return "", errors.Errorf("unknown image source %q", name)
+}
+
+// ImageCandidate is an image the page offers as its preview.
+type ImageCandidate struct {
+	URL    string
+	Source Source
+}
+
+// maxContentImages bounds the <img> elements kept from the page content.
+const maxContentImages = 5
+
+// contentSelectors find the main content of a page, best first.
+var contentSelectors = []string{"main", "article", "[role=main]", "body"}
+
+// boilerplateSelectors match the parts of a page around the content.
+const boilerplateSelectors = "header, footer, nav, aside, [role=banner], [role=navigation], [role=complementary]"
+
+// decorativeWords mark images that are not about the page when found in
+// their src, id, class or alt.
+var decorativeWords = map[string]bool{
+	"logo": true, "avatar": true, "icon": true, "sprite": true, "badge": true,
+	"emoji": true, "spinner": true, "spacer": true, "pixel": true,
+	"tracking": true, "tracker": true, "beacon": true,
+	"ad": true, "ads": true, "advert": true, "advertisement": true, "banner": true, "sponsor": true,
+}
+
+// parseImages reads the images that are not declared in meta tags:
+// itemprop=image microdata and large <img> elements of the main content.
+func (m *Metadata) parseImages(doc *goquery.Document, base *url.URL, opts *Options) {
+	doc.Find("[itemprop=image]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		value := s.AttrOr("content", s.AttrOr("href", s.AttrOr("src", "")))
+		if strings.TrimSpace(value) == "" {
+			return true
+		}
+		m.HTML.ItemPropImage = m.ResolveURL(value, "itemprop:image", base)
+		return m.HTML.ItemPropImage == ""
+	})
+
+	content := doc.Selection
+	for _, selector := range contentSelectors {
+		if found := doc.Find(selector).First(); found.Length() > 0 {
+			content = found
+			break
+		}
+	}
+	content.Find("img").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		img := Image{
+			Width:  parseDimension(s.AttrOr("width", "")),
+			Height: parseDimension(s.AttrOr("height", "")),
+			Alt:    strings.TrimSpace(s.AttrOr("alt", "")),
+		}
+		if img.Width < opts.MinContentImageWidth || img.Height < opts.MinContentImageHeight {
+			return true
+		}
+		src := s.AttrOr("src", "")
+		if src == "" || strings.HasPrefix(src, "data:") {
+			// lazy loading placeholders keep the real image elsewhere
+			src = s.AttrOr("data-src", "")
+		}
+		// images that cannot be resolved are not worth reporting here
+		if img.URL, _ = resolveURL(src, base); img.URL == "" {
+			return true
+		}
+		if isDecorative(s, img.URL) || s.Closest(boilerplateSelectors).Length() > 0 {
+			return true
+		}
+		m.ContentImages = append(m.ContentImages, img)
+		return len(m.ContentImages) < maxContentImages
+	})
+}
+
+// isDecorative reports whether an <img> looks like a logo, an ad or a
+// tracking pixel rather than an illustration.
+func isDecorative(s *goquery.Selection, src string) bool {
+	if u, err := url.Parse(src); err == nil {
+		src = u.Path
+	}
+	text := strings.Join([]string{src, s.AttrOr("id", ""), s.AttrOr("class", ""), s.AttrOr("alt", "")}, " ")
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	for _, word := range words {
+		if decorativeWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// imageCandidates lists the images of every source in sources, in order,
+// without duplicates.
+func (m *Metadata) imageCandidates(sources []Source) []ImageCandidate {
+	var candidates []ImageCandidate
+	seen := make(map[string]bool)
+	add := func(u string, source Source) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			candidates = append(candidates, ImageCandidate{URL: u, Source: source})
+		}
+	}
+	for _, source := range sources {
+		switch source {
+		case SourceOpenGraph:
+			for _, img := range m.OpenGraph.Images {
+				add(img.URL, source)
+				add(img.SecureURL, source)
+			}
+		case SourceTwitter:
+			add(m.Twitter.Image, source)
+		case SourceLink:
+			if link := m.FindLink("image_src"); link != nil {
+				add(link.Href, source)
+			}
+		case SourceJSONLD:
+			add(m.StructuredData.Image, source)
+		case SourceMicrodata:
+			add(m.HTML.ItemPropImage, source)
+		case SourceContent:
+			for _, img := range m.ContentImages {
+				add(img.URL, source)
+			}
+		}
+	}
+	return candidates
+}
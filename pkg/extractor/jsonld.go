@@ -0,0 +1,311 @@
+package extractor
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// StructuredData is the normalized subset of a page's JSON-LD.
+type StructuredData struct {
+	// Types lists the @type of every top level node, e.g. NewsArticle.
+	Types         []string
+	Name          string
+	Description   string
+	Image         string
+	Authors       []string
+	DatePublished string
+	DateModified  string
+	Price         string
+	PriceCurrency string
+	Availability  string
+	RatingValue   float64
+	RatingCount   int
+	Breadcrumb    []Breadcrumb
+	// Raw holds every JSON-LD object as parsed, with @graph arrays flattened.
+	Raw []map[string]interface{}
+}
+
+// Breadcrumb is an entry of a schema.org BreadcrumbList.
+type Breadcrumb struct {
+	Name string
+	URL  string
+}
+
+// primaryTypes are the schema.org types describing the page itself, in
+// order of preference, when picking the node used for title fallbacks.
+var primaryTypes = []string{
+	"NewsArticle", "Article", "BlogPosting", "Report", "Product", "Recipe",
+	"VideoObject", "Event", "Book", "Movie", "Course", "JobPosting",
+	"LocalBusiness", "Organization", "WebPage", "WebSite",
+}
+
+func (m *Metadata) parseJSONLD(doc *goquery.Document, base *url.URL) {
+	sd := &m.StructuredData
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var value interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(s.Text())), &value); err != nil {
+			return
+		}
+		sd.Raw = append(sd.Raw, topLevelNodes(value)...)
+	})
+	if len(sd.Raw) == 0 {
+		return
+	}
+
+	var nodes []map[string]interface{}
+	for _, node := range sd.Raw {
+		sd.Types = append(sd.Types, nodeTypes(node)...)
+		nodes = appendTypedNodes(nodes, node)
+	}
+
+	// the page may describe itself with several nodes, e.g. a WebPage and
+	// the Product on it, so fill each field from the most relevant node
+	for _, node := range nodesByType(nodes, primaryTypes) {
+		setOnce(&sd.Name, firstText(node["headline"], node["name"]))
+		setOnce(&sd.Description, text(node["description"]))
+		if img := firstImage(node["image"], node["thumbnailUrl"]); img != "" && sd.Image == "" {
+			sd.Image = m.ResolveURL(img, "jsonld:image", base)
+		}
+	}
+	if primary := findNode(nodes, primaryTypes...); primary != nil {
+		sd.Authors = names(primary["author"])
+		sd.DatePublished = firstText(primary["datePublished"], primary["uploadDate"], primary["startDate"])
+		sd.DateModified = text(primary["dateModified"])
+	}
+	if product := findNode(nodes, "Product"); product != nil {
+		offer := firstObject(product["offers"])
+		sd.Price = firstText(offer["price"], offer["lowPrice"])
+		sd.PriceCurrency = text(offer["priceCurrency"])
+		sd.Availability = strings.TrimPrefix(strings.TrimPrefix(text(offer["availability"]), "https://schema.org/"), "http://schema.org/")
+	}
+	if rated := findRated(nodes); rated != nil {
+		rating := firstObject(rated["aggregateRating"])
+		sd.RatingValue, _ = strconv.ParseFloat(text(rating["ratingValue"]), 64)
+		count, _ := strconv.ParseFloat(firstText(rating["ratingCount"], rating["reviewCount"]), 64)
+		sd.RatingCount = int(count)
+	}
+	if list := findNode(nodes, "BreadcrumbList"); list != nil {
+		sd.Breadcrumb = m.breadcrumb(list, base)
+	}
+}
+
+func (m *Metadata) breadcrumb(list map[string]interface{}, base *url.URL) []Breadcrumb {
+	type positioned struct {
+		position float64
+		crumb    Breadcrumb
+	}
+	var items []positioned
+	for i, element := range objects(list["itemListElement"]) {
+		item := firstObject(element["item"])
+		crumb := Breadcrumb{Name: firstText(element["name"], item["name"])}
+		// the item is either the URL itself or an object naming it
+		href, _ := element["item"].(string)
+		if href = strings.TrimSpace(href); href == "" {
+			href = firstText(item["@id"], item["url"])
+		}
+		if href != "" {
+			crumb.URL = m.ResolveURL(href, "jsonld:breadcrumb", base)
+		}
+		if crumb.Name == "" && crumb.URL == "" {
+			continue
+		}
+		position, err := strconv.ParseFloat(text(element["position"]), 64)
+		if err != nil {
+			position = float64(i + 1)
+		}
+		items = append(items, positioned{position, crumb})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].position < items[j].position })
+	crumbs := make([]Breadcrumb, 0, len(items))
+	for _, item := range items {
+		crumbs = append(crumbs, item.crumb)
+	}
+	return crumbs
+}
+
+// topLevelNodes returns the objects of a JSON-LD document, flattening
+// arrays and @graph containers.
+func topLevelNodes(value interface{}) []map[string]interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		var nodes []map[string]interface{}
+		for _, item := range v {
+			nodes = append(nodes, topLevelNodes(item)...)
+		}
+		return nodes
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			return topLevelNodes(graph)
+		}
+		return []map[string]interface{}{v}
+	}
+	return nil
+}
+
+// appendTypedNodes appends node and every nested object with an @type.
+func appendTypedNodes(nodes []map[string]interface{}, value interface{}) []map[string]interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			nodes = appendTypedNodes(nodes, item)
+		}
+	case map[string]interface{}:
+		if _, ok := v["@type"]; ok {
+			nodes = append(nodes, v)
+		}
+		// walk keys in a stable order so the first match is deterministic
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if key != "@context" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			nodes = appendTypedNodes(nodes, v[key])
+		}
+	}
+	return nodes
+}
+
+func nodeTypes(node map[string]interface{}) []string {
+	switch t := node["@type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// findNode returns the first node of the earliest listed type.
+func findNode(nodes []map[string]interface{}, types ...string) map[string]interface{} {
+	if matches := nodesByType(nodes, types); len(matches) > 0 {
+		return matches[0]
+	}
+	return nil
+}
+
+// nodesByType returns the nodes of the listed types, in the order of types.
+func nodesByType(nodes []map[string]interface{}, types []string) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, typ := range types {
+		for _, node := range nodes {
+			for _, t := range nodeTypes(node) {
+				if t == typ {
+					result = append(result, node)
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+func findRated(nodes []map[string]interface{}) map[string]interface{} {
+	for _, node := range nodes {
+		if _, ok := node["aggregateRating"]; ok {
+			return node
+		}
+	}
+	return nil
+}
+
+// text returns a JSON-LD value as a string. Objects are represented by
+// their name, @value or @id.
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		if len(v) > 0 {
+			return text(v[0])
+		}
+	case map[string]interface{}:
+		return firstText(v["name"], v["@value"], v["@id"])
+	}
+	return ""
+}
+
+func firstText(values ...interface{}) string {
+	for _, v := range values {
+		if s := text(v); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// firstImage returns the first image URL of an ImageObject, URL string or
+// a list of either.
+func firstImage(values ...interface{}) string {
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			if s := strings.TrimSpace(v); s != "" {
+				return s
+			}
+		case []interface{}:
+			if s := firstImage(v...); s != "" {
+				return s
+			}
+		case map[string]interface{}:
+			if s := firstText(v["url"], v["contentUrl"], v["@id"]); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// names returns the names of a Person, Organization, string or a list of them.
+func names(value interface{}) []string {
+	var result []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			result = append(result, names(item)...)
+		}
+	default:
+		if s := text(v); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func objects(value interface{}) []map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}
+	case []interface{}:
+		var result []map[string]interface{}
+		for _, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				result = append(result, obj)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func firstObject(value interface{}) map[string]interface{} {
+	if objs := objects(value); len(objs) > 0 {
+		return objs[0]
+	}
+	return map[string]interface{}{}
+}
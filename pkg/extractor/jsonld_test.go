@@ -0,0 +1,145 @@
+package extractor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONLD(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		// raw is the number of top level nodes
+		raw  int
+		want StructuredData
+	}{
+		{
+			name: "graph",
+			blocks: []string{`{"@context": "https://schema.org", "@graph": [
+				{"@type": "WebSite", "name": "Example", "url": "https://example.com/"},
+				{"@type": "WebPage", "name": "Page name", "description": "Page description"},
+				{"@type": "NewsArticle", "headline": "Headline", "image": {"@type": "ImageObject", "url": "/lead.jpg"},
+				 "datePublished": "2024-05-01", "dateModified": "2024-05-02"}
+			]}`},
+			raw: 3,
+			want: StructuredData{
+				Types:         []string{"WebSite", "WebPage", "NewsArticle"},
+				Name:          "Headline",
+				Description:   "Page description",
+				Image:         "https://example.com/lead.jpg",
+				DatePublished: "2024-05-01",
+				DateModified:  "2024-05-02",
+			},
+		},
+		{
+			name: "array of types",
+			blocks: []string{`{"@type": ["Thing", "Product"], "name": "Widget", "image": ["https://cdn.example.com/w1.png", "https://cdn.example.com/w2.png"],
+				"offers": [{"@type": "Offer", "price": 9.5, "priceCurrency": "EUR", "availability": "https://schema.org/InStock"}],
+				"aggregateRating": {"ratingValue": "4.5", "reviewCount": "12"}}`},
+			raw: 1,
+			want: StructuredData{
+				Types:         []string{"Thing", "Product"},
+				Name:          "Widget",
+				Image:         "https://cdn.example.com/w1.png",
+				Price:         "9.5",
+				PriceCurrency: "EUR",
+				Availability:  "InStock",
+				RatingValue:   4.5,
+				RatingCount:   12,
+			},
+		},
+		{
+			name: "nested author objects",
+			blocks: []string{`{"@type": "BlogPosting", "headline": "Post",
+				"author": [{"@type": "Person", "name": "Alice"}, {"@type": "Organization", "@id": "https://example.com/#org"}]}`},
+			raw: 1,
+			want: StructuredData{
+				Types:   []string{"BlogPosting"},
+				Name:    "Post",
+				Authors: []string{"Alice", "https://example.com/#org"},
+			},
+		},
+		{
+			name:   "author as a string",
+			blocks: []string{`{"@type": "Article", "headline": "Post", "author": " Bob "}`},
+			raw:    1,
+			want: StructuredData{
+				Types:   []string{"Article"},
+				Name:    "Post",
+				Authors: []string{"Bob"},
+			},
+		},
+		{
+			name: "top level array and breadcrumb",
+			blocks: []string{`[{"@type": "Article", "name": "Story"},
+				{"@type": "BreadcrumbList", "itemListElement": [
+					{"@type": "ListItem", "position": 2, "name": "News", "item": "/news"},
+					{"@type": "ListItem", "position": 1, "item": {"@id": "https://example.com/", "name": "Home"}}
+				]}]`},
+			raw: 2,
+			want: StructuredData{
+				Types: []string{"Article", "BreadcrumbList"},
+				Name:  "Story",
+				Breadcrumb: []Breadcrumb{
+					{Name: "Home", URL: "https://example.com/"},
+					{Name: "News", URL: "https://example.com/news"},
+				},
+			},
+		},
+		{
+			name: "malformed blocks skipped",
+			blocks: []string{
+				`{"@type": "Article", "headline": "Broken",}`,
+				`not json at all`,
+				``,
+				`{"@type": "Article", "headline": "Valid"}`,
+			},
+			raw: 1,
+			want: StructuredData{
+				Types: []string{"Article"},
+				Name:  "Valid",
+			},
+		},
+		{
+			name:   "only malformed blocks",
+			blocks: []string{`{"@type": "Article"`},
+		},
+	}
+	for _, tt := range tests {
+		var page strings.Builder
+		page.WriteString("<html><head></head><body>")
+		for _, block := range tt.blocks {
+			page.WriteString(`<script type="application/ld+json">` + block + `</script>`)
+		}
+		page.WriteString("</body></html>")
+		sd := extract(t, page.String(), nil).StructuredData
+		if len(sd.Raw) != tt.raw {
+			t.Errorf("%s: %d raw nodes, want %d", tt.name, len(sd.Raw), tt.raw)
+		}
+		sd.Raw = nil
+		if !reflect.DeepEqual(sd, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, sd, tt.want)
+		}
+	}
+}
+
+func TestJSONLDTitleFallback(t *testing.T) {
+	const page = `<html><head><title>HTML title</title></head><body>
+<script type="application/ld+json">{"@type": "Product", "name": "Widget", "description": "A widget"}</script>
+</body></html>`
+	m := extract(t, page, nil)
+	if m.Title != "Widget" || m.Sources[FieldTitle] != SourceJSONLD {
+		t.Errorf("title = %q from %q, want Widget from jsonld", m.Title, m.Sources[FieldTitle])
+	}
+	if m.Description != "A widget" || m.Sources[FieldDescription] != SourceJSONLD {
+		t.Errorf("description = %q from %q", m.Description, m.Sources[FieldDescription])
+	}
+
+	opts := DefaultOptions()
+	opts.JSONLD = false
+	opts.ImageSources = []Source{SourceOpenGraph}
+	if m := extract(t, page, opts); m.Title != "HTML title" || len(m.StructuredData.Raw) != 0 {
+		t.Errorf("with JSON-LD off: title %q, %d nodes", m.Title, len(m.StructuredData.Raw))
+	}
+}
@@ -0,0 +1,226 @@
+package extractor
+
+import "strings"
+
+// Source identifies where a metadata value was found.
+type Source string
+
+const (
+	SourceOpenGraph Source = "opengraph"
+	SourceTwitter   Source = "twitter"
+	SourceHTML      Source = "html"
+	SourceLink      Source = "link"
+	SourceDocument  Source = "document"
+	SourceOEmbed    Source = "oembed"
+	SourceJSONLD    Source = "jsonld"
+	SourceMicrodata Source = "microdata"
+	SourceContent   Source = "content"
+)
+
+// Field names used as keys in Metadata.Sources.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldImage       = "image"
+	FieldURL         = "url"
+	FieldSiteName    = "siteName"
+	FieldFavicon     = "favicon"
+)
+
+// Metadata is everything the extractor found in a document. The top level
+// fields are the resolved values after applying the fallback rules, the
+// nested structs hold the values exactly as each source declared them.
+type Metadata struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+	SiteName    string
+	Favicon     string
+	// Sources records which source each resolved field came from.
+	Sources map[string]Source
+
+	OpenGraph OpenGraph
+	Twitter   Twitter
+	HTML      HTML
+	Links     []Link
+	// Icons are the site icons, best first.
+	Icons []Icon
+	// ImageCandidates are the images found for the page, in the order of
+	// the configured image sources. Image is the first one.
+	ImageCandidates []ImageCandidate
+	// ContentImages are large <img> elements of the main content.
+	ContentImages []Image
+	// StructuredData is read from the page's JSON-LD blocks.
+	StructuredData StructuredData
+	// Properties holds the og:, twitter:, article:, book: and profile: meta
+	// tags in document order.
+	Properties []Property
+	// InvalidURLs lists URL-valued fields that were dropped.
+	InvalidURLs []InvalidURL
+}
+
+// Property is a single namespaced <meta> tag such as og:title.
+type Property struct {
+	Name    string
+	Content string
+}
+
+// OpenGraph holds the og: properties of a document, together with the
+// article:, book: and profile: properties of those object types.
+type OpenGraph struct {
+	Title            string
+	Description      string
+	Type             string
+	URL              string
+	SiteName         string
+	Locale           string
+	LocaleAlternates []string
+	Images           []Image
+	Videos           []Video
+	Audios           []Audio
+	Article          Article
+	Book             Book
+	Profile          Profile
+}
+
+// Image is an og:image together with its structured properties.
+type Image struct {
+	URL       string
+	SecureURL string
+	Type      string
+	Width     int
+	Height    int
+	Alt       string
+}
+
+// Video is an og:video together with its structured properties.
+type Video struct {
+	URL       string
+	SecureURL string
+	Type      string
+	Width     int
+	Height    int
+}
+
+// Audio is an og:audio together with its structured properties.
+type Audio struct {
+	URL       string
+	SecureURL string
+	Type      string
+}
+
+// Article holds the article: properties of an og:type=article page.
+type Article struct {
+	PublishedTime  string
+	ModifiedTime   string
+	ExpirationTime string
+	Authors        []string
+	Section        string
+	Tags           []string
+}
+
+// Book holds the book: properties of an og:type=book page.
+type Book struct {
+	Authors     []string
+	ISBN        string
+	ReleaseDate string
+	Tags        []string
+}
+
+// Profile holds the profile: properties of an og:type=profile page.
+type Profile struct {
+	FirstName string
+	LastName  string
+	Username  string
+	Gender    string
+}
+
+// Twitter holds the twitter: card properties of a document.
+type Twitter struct {
+	Card        string
+	Site        string
+	Creator     string
+	Title       string
+	Description string
+	Image       string
+	ImageAlt    string
+}
+
+// HTML holds values from standard HTML elements and meta tags.
+type HTML struct {
+	Title       string
+	Description string
+	Keywords    string
+	Author      string
+	ThemeColor  string
+	Language    string
+	// ItemPropImage is the first itemprop=image microdata value.
+	ItemPropImage string
+}
+
+// Link is a <link> element with a rel attribute.
+type Link struct {
+	Rel   string
+	Href  string
+	Type  string
+	Sizes string
+	Title string
+}
+
+// FindLink returns the first link whose rel list contains rel.
+func (m *Metadata) FindLink(rel string) *Link {
+	for i := range m.Links {
+		if hasRel(m.Links[i].Rel, rel) {
+			return &m.Links[i]
+		}
+	}
+	return nil
+}
+
+// FindLinkByType returns the first link with the given rel and type.
+func (m *Metadata) FindLinkByType(rel, typ string) *Link {
+	for i := range m.Links {
+		if hasRel(m.Links[i].Rel, rel) && strings.EqualFold(m.Links[i].Type, typ) {
+			return &m.Links[i]
+		}
+	}
+	return nil
+}
+
+// SetFallback sets a resolved field from a source outside the document,
+// such as an oEmbed response, if no value was found for it yet. A value
+// guessed from the page content gives way too.
+func (m *Metadata) SetFallback(field, value string, source Source) {
+	var dst *string
+	switch field {
+	case FieldTitle:
+		dst = &m.Title
+	case FieldDescription:
+		dst = &m.Description
+	case FieldImage:
+		dst = &m.Image
+	case FieldURL:
+		dst = &m.URL
+	case FieldSiteName:
+		dst = &m.SiteName
+	case FieldFavicon:
+		dst = &m.Favicon
+	default:
+		return
+	}
+	if (*dst == "" || m.Sources[field] == SourceContent) && value != "" {
+		*dst = value
+		m.Sources[field] = source
+	}
+}
+
+// Property returns the content of the first property called name.
+func (m *Metadata) Property(name string) string {
+	for _, p := range m.Properties {
+		if p.Name == name {
+			return p.Content
+		}
+	}
+	return ""
+}
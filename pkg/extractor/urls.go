@@ -0,0 +1,149 @@
+package extractor
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// InvalidURL is a URL-valued field that could not be resolved. The field is
+// cleared in the extracted metadata and reported here instead.
+type InvalidURL struct {
+	Field  string
+	Value  string
+	Reason string
+}
+
+// entityPattern matches HTML entities left in attribute values by pages
+// that escape their URLs twice, e.g. "&amp;amp;".
+var entityPattern = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z]+);`)
+
+// documentBase returns the URL relative references are resolved against:
+// the first <base href> resolved against the document URL, or the document
+// URL itself.
+func documentBase(doc *goquery.Document, docURL *url.URL) *url.URL {
+	if docURL == nil {
+		return nil
+	}
+	href, ok := doc.Find("base[href]").First().Attr("href")
+	if !ok {
+		return docURL
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return docURL
+	}
+	base := docURL.ResolveReference(ref)
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return docURL
+	}
+	return base
+}
+
+// urlProperties are the meta properties whose content is a URL.
+var urlProperties = map[string]bool{
+	"og:url":                true,
+	"og:image":              true,
+	"og:image:url":          true,
+	"og:image:secure_url":   true,
+	"og:video":              true,
+	"og:video:url":          true,
+	"og:video:secure_url":   true,
+	"og:audio":              true,
+	"og:audio:url":          true,
+	"og:audio:secure_url":   true,
+	"twitter:image":         true,
+	"twitter:image:src":     true,
+	"twitter:player":        true,
+	"twitter:player:stream": true,
+}
+
+// consumedLinkRels are the link relations whose href is used, besides the
+// icons and the oEmbed alternates.
+var consumedLinkRels = []string{"canonical", "image_src", "manifest"}
+
+// resolveURLs makes every URL-valued property and link absolute against
+// base. Values that cannot be resolved to an http or https URL are dropped
+// and recorded in InvalidURLs, except for links that are never used and
+// links to other schemes such as android-app: or data:, which are dropped
+// silently.
+func (m *Metadata) resolveURLs(base *url.URL) {
+	properties := m.Properties[:0]
+	for _, p := range m.Properties {
+		if urlProperties[p.Name] {
+			p.Content = m.ResolveURL(p.Content, p.Name, base)
+		}
+		if p.Content != "" {
+			properties = append(properties, p)
+		}
+	}
+	m.Properties = properties
+
+	links := m.Links[:0]
+	for _, link := range m.Links {
+		resolved, reason := resolveURL(link.Href, base)
+		if reason != "" && consumedLink(link) && !otherScheme(link.Href) {
+			m.InvalidURLs = append(m.InvalidURLs, InvalidURL{Field: "link[" + link.Rel + "]", Value: link.Href, Reason: reason})
+		}
+		link.Href = resolved
+		if link.Href != "" {
+			links = append(links, link)
+		}
+	}
+	m.Links = links
+}
+
+// consumedLink reports whether the href of link is used for the metadata.
+func consumedLink(link Link) bool {
+	for _, rels := range [][]string{iconRels, consumedLinkRels} {
+		for _, rel := range rels {
+			if hasRel(link.Rel, rel) {
+				return true
+			}
+		}
+	}
+	return hasRel(link.Rel, "alternate") && strings.HasSuffix(strings.ToLower(link.Type), "+oembed")
+}
+
+// otherScheme reports whether raw is an absolute URL whose scheme is not
+// http or https.
+func otherScheme(raw string) bool {
+	ref, err := url.Parse(strings.TrimSpace(raw))
+	return err == nil && ref.IsAbs() && ref.Scheme != "http" && ref.Scheme != "https"
+}
+
+// ResolveURL resolves value against base like the document's own URLs are
+// resolved, recording it in InvalidURLs under field if it is unusable.
+func (m *Metadata) ResolveURL(value, field string, base *url.URL) string {
+	resolved, reason := resolveURL(value, base)
+	if reason != "" {
+		m.InvalidURLs = append(m.InvalidURLs, InvalidURL{Field: field, Value: value, Reason: reason})
+	}
+	return resolved
+}
+
+// resolveURL returns raw as an absolute http(s) URL, or an empty string and
+// the reason it is unusable.
+func resolveURL(raw string, base *url.URL) (string, string) {
+	raw = strings.TrimSpace(entityPattern.ReplaceAllStringFunc(raw, html.UnescapeString))
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", "malformed URL"
+	}
+	if !ref.IsAbs() {
+		if base == nil {
+			return "", "relative URL without a base"
+		}
+		ref = base.ResolveReference(ref)
+	}
+	if ref.Scheme != "http" && ref.Scheme != "https" {
+		return "", "unsupported scheme " + ref.Scheme
+	}
+	if ref.Host == "" {
+		return "", "missing host"
+	}
+	return ref.String(), ""
+}
@@ -0,0 +1,33 @@
+package extractor
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestResolveURLsReportsOnlyConsumedLinks(t *testing.T) {
+	const page = `<html><head>
+<link rel="alternate" href="android-app://com.example/https/example.com/a">
+<link rel="alternate" href="ios-app://123/https/example.com/a">
+<link rel="preload" href="http://[bad">
+<link rel="icon" href="data:image/png;base64,AAAA">
+<link rel="icon" href="/favicon.ico">
+<link rel="canonical" href="http://[bad">
+</head></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/a")
+	m := Extract(doc, base, nil)
+
+	if len(m.InvalidURLs) != 1 || m.InvalidURLs[0].Field != "link[canonical]" {
+		t.Errorf("InvalidURLs = %+v, want only the canonical link", m.InvalidURLs)
+	}
+	if m.Favicon != "https://example.com/favicon.ico" {
+		t.Errorf("Favicon = %q", m.Favicon)
+	}
+}
@@ -0,0 +1,127 @@
+package fetcher
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+const (
+	// prescanBytes is how much of a document browsers search for a
+	// <meta> declaring the encoding.
+	prescanBytes = 1024
+	// guessBytes is how much of an undeclared document is decoded to
+	// guess its encoding.
+	guessBytes = 64 << 10
+)
+
+// guesses are the multi-byte encodings tried for undeclared documents that
+// are not UTF-8, with characters common in text written in them. Earlier
+// encodings win ties.
+var guesses = []struct {
+	name   string
+	common string
+}{
+	{"shift_jis", "のにはをたがでてとしれさいうかなるこ"},
+	{"euc-jp", "のにはをたがでてとしれさいうかなるこ"},
+	{"gbk", "的是不了在人有我他这中大来上国个到说们为和"},
+	{"big5", "的是不了在人有我他這中大來上國個到說們為和"},
+	{"euc-kr", "이다는의에하고을가로지서한기를니"},
+}
+
+// DecodeHTML returns body transcoded to UTF-8 and the name of the encoding
+// it was read as. The encoding is taken from a byte order mark, then the
+// charset of contentType, then a <meta charset> or http-equiv tag near the
+// start of the document. Undeclared bodies are UTF-8 when they are valid
+// UTF-8. Otherwise the Japanese, Chinese and Korean multi-byte encodings
+// are tried and the one whose text has the most common characters of its
+// language wins, falling back to windows-1252 as browsers do. Single-byte
+// encodings such as windows-1251 cannot be told apart that way, so pages in
+// them are only read correctly when they declare their encoding.
+func DecodeHTML(body []byte, contentType string) ([]byte, string) {
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	if !certain && name == "windows-1252" && !declaresCharset(body) {
+		if guess := guessEncoding(body); guess != "" {
+			enc, name = charset.Lookup(guess)
+		}
+	}
+	if name != "utf-8" {
+		// invalid sequences, such as a character cut off by the size
+		// limit, become U+FFFD rather than failing
+		if decoded, err := enc.NewDecoder().Bytes(body); err == nil {
+			body = decoded
+		}
+	}
+	// a byte order mark would otherwise end up in the title
+	return bytes.TrimPrefix(body, utf8BOM), name
+}
+
+// declaresCharset reports whether a <meta> tag within the prescanned start
+// of body declares an encoding.
+func declaresCharset(body []byte) bool {
+	if len(body) > prescanBytes {
+		body = body[:prescanBytes]
+	}
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "meta" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					return true
+				case "content":
+					if bytes.Contains(bytes.ToLower(val), []byte("charset=")) {
+						return true
+					}
+				}
+			}
+		}
+	}
+}
+
+// guessEncoding returns the name of the encoding among guesses that body
+// decodes cleanly in with the most common characters, or "" if none fits.
+func guessEncoding(body []byte) string {
+	if len(body) > guessBytes {
+		body = body[:guessBytes]
+	}
+	best, bestScore := "", 0
+	for _, guess := range guesses {
+		enc, _ := charset.Lookup(guess.name)
+		decoded, err := enc.NewDecoder().Bytes(body)
+		if err != nil {
+			continue
+		}
+		score, invalid := 0, 0
+		for _, r := range string(decoded) {
+			switch {
+			case r == utf8.RuneError:
+				invalid++
+			case r >= utf8.RuneSelf && strings.ContainsRune(guess.common, r):
+				score++
+			}
+		}
+		// a character cut off at the end of the sample is not a mismatch
+		if invalid > 1 {
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = guess.name, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,78 @@
+package fetcher
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var titlePattern = regexp.MustCompile(`<title>(.*)</title>`)
+
+func TestDecodeHTML(t *testing.T) {
+	const (
+		ja = "日本語のページのタイトルです"
+		zh = "这是一个中文网页的标题"
+		tw = "這是一個中文網頁的標題"
+		ko = "한국어 웹 페이지의 제목입니다"
+		ru = "Заголовок русской страницы"
+		fr = "Café à la crème brûlée"
+	)
+	tests := []struct {
+		file        string
+		contentType string
+		encoding    string
+		title       string
+	}{
+		{"header-windows-1251.html", "text/html; charset=windows-1251", "windows-1251", ru},
+		// the header wins over the meta tag
+		{"meta-shift_jis.html", "text/html; charset=shift_jis", "shift_jis", ja},
+		{"meta-shift_jis.html", "text/html", "shift_jis", ja},
+		{"meta-http-equiv-euc-kr.html", "text/html", "euc-kr", ko},
+		{"meta-windows-1252.html", "text/html", "windows-1252", fr},
+		// the byte order mark wins over the header
+		{"bom-utf-8.html", "text/html; charset=iso-8859-1", "utf-8", ja},
+		{"bom-utf-16le.html", "text/html", "utf-16le", ko},
+		{"undeclared-utf-8.html", "text/html", "utf-8", zh},
+		{"undeclared-shift_jis.html", "text/html", "shift_jis", ja},
+		{"undeclared-euc-jp.html", "text/html", "euc-jp", ja},
+		{"undeclared-gbk.html", "text/html", "gbk", zh},
+		{"undeclared-big5.html", "text/html", "big5", tw},
+		{"undeclared-euc-kr.html", "text/html", "euc-kr", ko},
+		{"undeclared-windows-1252.html", "", "windows-1252", fr},
+		// single-byte encodings need a declaration, see DecodeHTML
+		{"undeclared-windows-1251.html", "text/html", "windows-1252", "Çàãîëîâîê ðóññêîé ñòðàíèöû"},
+	}
+	for _, tt := range tests {
+		body, err := os.ReadFile(filepath.Join("testdata", "charset", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, encoding := DecodeHTML(body, tt.contentType)
+		title := ""
+		if m := titlePattern.FindSubmatch(decoded); m != nil {
+			title = string(m[1])
+		}
+		if encoding != tt.encoding || title != tt.title {
+			t.Errorf("%s with %q: read as %s with title %q, want %s with %q",
+				tt.file, tt.contentType, encoding, title, tt.encoding, tt.title)
+		}
+		if bytes.HasPrefix(decoded, utf8BOM) {
+			t.Errorf("%s: byte order mark kept", tt.file)
+		}
+	}
+}
+
+func TestDecodeHTMLCutOff(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "charset", "undeclared-shift_jis.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stop in the middle of the first character of the paragraph
+	cut := strings.Index(string(body), "<p>") + len("<p>") + 1
+	if _, encoding := DecodeHTML(body[:cut], "text/html"); encoding != "shift_jis" {
+		t.Errorf("cut off page read as %s, want shift_jis", encoding)
+	}
+}
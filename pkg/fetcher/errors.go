@@ -0,0 +1,93 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// Kind classifies why a fetch failed.
+type Kind string
+
+const (
+	KindInvalidURL       Kind = "invalid_url"
+	KindBlocked          Kind = "blocked"
+	KindUnreachable      Kind = "unreachable"
+	KindTimeout          Kind = "timeout"
+	KindCanceled         Kind = "canceled"
+	KindTooManyRedirects Kind = "too_many_redirects"
+	KindUpstreamStatus   Kind = "upstream_status"
+	KindNotHTML          Kind = "not_html"
+	KindNotImage         Kind = "not_image"
+	KindTooLarge         Kind = "too_large"
+	KindNotModified      Kind = "not_modified"
+	KindThrottled        Kind = "throttled"
+	KindDisallowed       Kind = "disallowed"
+)
+
+// ErrTooManyRedirects is returned when a fetch is redirected more than
+// Options.MaxRedirects times.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// Error is a failed fetch. Callers use Kind to decide how to report it.
+type Error struct {
+	Kind Kind
+	URL  string
+	// StatusCode is the upstream status for KindUpstreamStatus and
+	// KindNotModified.
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("fetch %s: %s (status %d): %v", e.URL, e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("fetch %s: %s: %v", e.URL, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// AsError returns the *Error in err's chain, if any.
+func AsError(err error) (*Error, bool) {
+	var fetchErr *Error
+	if errors.As(err, &fetchErr) {
+		return fetchErr, true
+	}
+	return nil, false
+}
+
+// ContextError wraps the error of a context that ended while waiting for
+// the fetch of rawURL: KindTimeout when its deadline passed and KindCanceled
+// when it was canceled.
+func ContextError(rawURL string, err error) *Error {
+	return classify(rawURL, err)
+}
+
+// classify wraps an error returned by the HTTP client into an *Error.
+func classify(rawURL string, err error) *Error {
+	if fetchErr, ok := AsError(err); ok {
+		return fetchErr
+	}
+	kind := KindUnreachable
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrBlocked):
+		kind = KindBlocked
+	case errors.Is(err, ErrThrottled):
+		kind = KindThrottled
+	case errors.Is(err, ErrTooManyRedirects):
+		kind = KindTooManyRedirects
+	case errors.Is(err, context.Canceled):
+		kind = KindCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = KindTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		kind = KindTimeout
+	}
+	return &Error{Kind: kind, URL: rawURL, Err: err}
+}
@@ -0,0 +1,431 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// Fetcher retrieves remote pages while refusing to connect to internal
+// destinations. DNS is resolved by the fetcher itself so every address,
+// including those reached through redirects, is checked before dialing.
+type Fetcher struct {
+	logger   logger.Logger
+	opts     *Options
+	policy   *policy
+	throttle *throttle
+	robots   *robots
+	profiles *profiles
+	metrics  *metrics
+	resolver resolver
+	dialer   *net.Dialer
+	client   *http.Client
+}
+
+// Options - configuration for Fetcher
+type Options struct {
+	AllowCIDRs []string
+	DenyCIDRs  []string
+	AllowHosts []string
+	DenyHosts  []string
+
+	ConnectTimeout time.Duration
+	HeaderTimeout  time.Duration
+	TotalTimeout   time.Duration
+	MaxBodyBytes   int64
+	StopAtHeadEnd  bool
+	MaxRedirects   int
+
+	// Politeness per registrable domain: a token bucket of HostRate
+	// requests per second with HostBurst tokens, at most HostMaxInFlight
+	// concurrent fetches, and requests waiting up to HostQueueTimeout for
+	// both. HostLimits overrides them as domain=rate/burst/inflight.
+	HostRate         float64
+	HostBurst        int
+	HostMaxInFlight  int
+	HostQueueTimeout time.Duration
+	HostLimits       []string
+
+	// Robots is RobotsOff, RobotsAnnotate to report what robots.txt says
+	// about each fetch, or RobotsEnforce to refuse disallowed paths. The
+	// files are evaluated for RobotsUserAgent and cached for RobotsTTL.
+	Robots          string
+	RobotsUserAgent string
+	RobotsTTL       time.Duration
+	RobotsCacheSize int
+
+	// Profile is the header profile used when neither the request nor a
+	// DomainProfiles rule (domain=profile) selects one. ProfilesFile is a
+	// JSON object of extra profiles by name, see Profile.
+	Profile        string
+	DomainProfiles []string
+	ProfilesFile   string
+}
+
+// DefaultOptions returns Options with conservative limits.
+func DefaultOptions() *Options {
+	return &Options{
+		ConnectTimeout: 3 * time.Second,
+		HeaderTimeout:  5 * time.Second,
+		TotalTimeout:   10 * time.Second,
+		MaxBodyBytes:   1 << 20,
+		StopAtHeadEnd:  true,
+		MaxRedirects:   5,
+
+		HostRate:         5,
+		HostBurst:        10,
+		HostMaxInFlight:  4,
+		HostQueueTimeout: 5 * time.Second,
+
+		Robots:          RobotsOff,
+		RobotsUserAgent: "opengraph-thumbnail",
+		RobotsTTL:       time.Hour,
+		RobotsCacheSize: 1024,
+
+		Profile: ProfileDefault,
+	}
+}
+
+// GetFlagSet returns flag set for Options
+func (o *Options) GetFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("fetcherOptions", pflag.ExitOnError)
+	flags.StringSliceVar(&o.AllowCIDRs, "fetch-allow-cidr", o.AllowCIDRs, "CIDRs the fetcher may connect to even if they are private")
+	flags.StringSliceVar(&o.DenyCIDRs, "fetch-deny-cidr", o.DenyCIDRs, "additional CIDRs the fetcher must never connect to")
+	flags.StringSliceVar(&o.AllowHosts, "fetch-allow-host", o.AllowHosts, "if set, only these hosts may be fetched (a leading dot matches subdomains)")
+	flags.StringSliceVar(&o.DenyHosts, "fetch-deny-host", o.DenyHosts, "hosts that must never be fetched (a leading dot matches subdomains)")
+	flags.DurationVar(&o.ConnectTimeout, "fetch-connect-timeout", o.ConnectTimeout, "timeout for establishing a connection, including TLS")
+	flags.DurationVar(&o.HeaderTimeout, "fetch-header-timeout", o.HeaderTimeout, "timeout for receiving response headers")
+	flags.DurationVar(&o.TotalTimeout, "fetch-total-timeout", o.TotalTimeout, "timeout for the whole fetch, including redirects and the body")
+	flags.Int64Var(&o.MaxBodyBytes, "fetch-max-body-bytes", o.MaxBodyBytes, "maximum number of body bytes read from a page")
+	flags.BoolVar(&o.StopAtHeadEnd, "fetch-stop-at-head-end", o.StopAtHeadEnd, "stop reading a page once </head> has been seen, unless the body is needed for JSON-LD or the page image")
+	flags.IntVar(&o.MaxRedirects, "fetch-max-redirects", o.MaxRedirects, "maximum number of redirects to follow")
+	flags.Float64Var(&o.HostRate, "fetch-host-rate", o.HostRate, "requests per second to the same registrable domain, 0 for no limit")
+	flags.IntVar(&o.HostBurst, "fetch-host-burst", o.HostBurst, "requests to the same registrable domain allowed in a burst")
+	flags.IntVar(&o.HostMaxInFlight, "fetch-host-max-in-flight", o.HostMaxInFlight, "concurrent requests to the same registrable domain, 0 for no limit")
+	flags.DurationVar(&o.HostQueueTimeout, "fetch-host-queue-timeout", o.HostQueueTimeout, "how long a request waits for a saturated domain before failing as throttled")
+	flags.StringVar(&o.Robots, "fetch-robots", o.Robots, "robots.txt handling: off, annotate or enforce")
+	flags.StringVar(&o.RobotsUserAgent, "fetch-robots-user-agent", o.RobotsUserAgent, "user agent token robots.txt rules are evaluated for")
+	flags.DurationVar(&o.RobotsTTL, "fetch-robots-ttl", o.RobotsTTL, "how long a robots.txt is cached")
+	flags.IntVar(&o.RobotsCacheSize, "fetch-robots-cache-size", o.RobotsCacheSize, "number of robots.txt files kept in memory")
+	flags.StringVar(&o.Profile, "fetch-profile", o.Profile, "default header profile: default, facebookexternalhit, twitterbot, browser or one from --fetch-profiles-file")
+	flags.StringSliceVar(&o.DomainProfiles, "fetch-domain-profile", o.DomainProfiles, "header profile per domain as domain=profile, a leading dot matches subdomains")
+	flags.StringVar(&o.ProfilesFile, "fetch-profiles-file", o.ProfilesFile, "JSON file of extra header profiles by name")
+	flags.StringSliceVar(&o.HostLimits, "fetch-host-limit", o.HostLimits, "per domain limits as domain=rate/burst/inflight, e.g. example.com=1/2/1")
+	return flags
+}
+
+// Response is a fetched page with its body already read.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	// FinalURL is the URL of the last request after following redirects.
+	FinalURL *url.URL
+	Body     []byte
+	// Truncated is set when reading stopped before the end of the body.
+	Truncated bool
+	// Robots is what robots.txt says about the URL, unchecked when the
+	// fetcher ignores robots.txt.
+	Robots RobotsVerdict
+	// Profile is the name of the header profile the request was made with.
+	Profile string
+}
+
+// New - constructor for Fetcher
+func New(opts *Options, logger logger.Logger) (*Fetcher, error) {
+	p, err := newPolicy(opts)
+	if err != nil {
+		return nil, err
+	}
+	t, err := newThrottle(opts)
+	if err != nil {
+		return nil, err
+	}
+	f := &Fetcher{
+		logger:   logger,
+		opts:     opts,
+		policy:   p,
+		throttle: t,
+		metrics:  newMetrics(),
+		resolver: net.DefaultResolver,
+		dialer:   &net.Dialer{Timeout: opts.ConnectTimeout},
+	}
+	if f.robots, err = newRobots(f, opts); err != nil {
+		return nil, err
+	}
+	if f.profiles, err = newProfiles(opts); err != nil {
+		return nil, err
+	}
+	f.client = &http.Client{
+		Transport: &http.Transport{
+			// never use an environment proxy, it would bypass the address checks
+			Proxy:                 nil,
+			DialContext:           f.dialContext,
+			TLSHandshakeTimeout:   opts.ConnectTimeout,
+			ResponseHeaderTimeout: opts.HeaderTimeout,
+			MaxIdleConnsPerHost:   4,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: f.checkRedirect,
+	}
+	return f, nil
+}
+
+// Request describes a single fetch.
+type Request struct {
+	URL    string
+	Header http.Header
+	// HTML marks the body as an HTML page. Reading may then stop at the end
+	// of the head, and a body cut off by the size limit is accepted as long
+	// as the whole head was read. Any other body must fit in the limit.
+	HTML bool
+	// FullBody reads an HTML page to the end, within the size limit, for
+	// callers that need more than the head.
+	FullBody bool
+	// MaxBytes overrides Options.MaxBodyBytes when positive.
+	MaxBytes int64
+	// Profile names the header profile to use, see WithProfile for the
+	// fallbacks when empty.
+	Profile string
+	// Partial accepts a body cut off by the size limit, for callers that
+	// only need the start of a resource.
+	Partial bool
+}
+
+// Fetch downloads the HTML page at rawURL. See Do.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Response, error) {
+	return f.Do(ctx, &Request{URL: rawURL, HTML: true})
+}
+
+// Do performs req, following redirects, and reads at most the configured
+// number of body bytes. The whole exchange is bounded by
+// Options.TotalTimeout and by ctx. HTML page fetches are made conditional
+// when ctx carries validators, see WithValidators. robots.txt is consulted
+// according to Options.Robots, for the URL and for redirect targets.
+func (f *Fetcher) Do(ctx context.Context, r *Request) (*Response, error) {
+	res, err := f.do(ctx, r, true)
+	f.metrics.request(err)
+	return res, err
+}
+
+func (f *Fetcher) do(ctx context.Context, r *Request, checkRobots bool) (*Response, error) {
+	rawURL := r.URL
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, &Error{Kind: KindInvalidURL, URL: rawURL, Err: err}
+	}
+	if err := f.policy.checkURL(u); err != nil {
+		return nil, classify(rawURL, err)
+	}
+	profileName, profile, err := f.profiles.resolve(ctx, r.Profile, u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	verdict := RobotsUnchecked
+	if checkRobots {
+		if verdict, err = f.robots.check(ctx, u); err != nil {
+			return nil, err
+		}
+		ctx = context.WithValue(ctx, robotsCheckKey{}, true)
+	}
+	// queueing for a busy domain does not count against the fetch timeouts
+	release, err := f.throttle.acquire(ctx, u.Hostname())
+	if err != nil {
+		return nil, classify(rawURL, err)
+	}
+	defer release()
+	if f.opts.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.opts.TotalTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, &Error{Kind: KindInvalidURL, URL: rawURL, Err: err}
+	}
+	for key, values := range r.Header {
+		req.Header[key] = values
+	}
+	profile.apply(req.Header)
+	conditional := r.HTML && setValidators(ctx, req.Header)
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, classify(rawURL, err)
+	}
+	defer res.Body.Close()
+
+	if conditional && res.StatusCode == http.StatusNotModified {
+		recordPageInfo(ctx, res.StatusCode, res.Header)
+		return nil, &Error{
+			Kind:       KindNotModified,
+			URL:        rawURL,
+			StatusCode: res.StatusCode,
+			Err:        errors.New("page not modified"),
+		}
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, &Error{
+			Kind:       KindUpstreamStatus,
+			URL:        rawURL,
+			StatusCode: res.StatusCode,
+			Err:        errors.Errorf("upstream responded %s", res.Status),
+		}
+	}
+
+	limit := r.MaxBytes
+	if limit <= 0 {
+		limit = f.opts.MaxBodyBytes
+	}
+	body, truncated, sawHeadEnd, err := f.readBody(res.Body, limit, r.HTML && f.opts.StopAtHeadEnd && !r.FullBody)
+	if err != nil {
+		return nil, classify(rawURL, errors.Wrap(err, "reading body"))
+	}
+	if truncated && !r.Partial && !(r.HTML && sawHeadEnd) {
+		return nil, &Error{
+			Kind: KindTooLarge,
+			URL:  rawURL,
+			Err:  errors.Errorf("body exceeds %d bytes", limit),
+		}
+	}
+	if checkRobots && f.robots.mode == RobotsAnnotate && res.Request.URL.String() != u.String() {
+		// report what robots.txt says about the page actually fetched
+		if verdict, err = f.robots.check(ctx, res.Request.URL); err != nil {
+			return nil, err
+		}
+	}
+	response := &Response{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		FinalURL:   res.Request.URL,
+		Body:       body,
+		Truncated:  truncated,
+		Robots:     verdict,
+		Profile:    profileName,
+	}
+	if r.HTML {
+		recordPageInfo(ctx, res.StatusCode, res.Header)
+	}
+	return response, nil
+}
+
+// readBody reads r until EOF, the byte limit, or (if stopAtHeadEnd) the end
+// of the document head, whichever comes first. It reports whether reading
+// stopped early and whether the end of the head was seen.
+func (f *Fetcher) readBody(r io.Reader, limit int64, stopAtHeadEnd bool) (body []byte, truncated, sawHeadEnd bool, err error) {
+	if limit <= 0 {
+		limit = DefaultOptions().MaxBodyBytes
+	}
+	var buf bytes.Buffer
+	chunk := make([]byte, 32<<10)
+	for int64(buf.Len()) < limit {
+		n, readErr := r.Read(chunk[:minInt64(int64(len(chunk)), limit-int64(buf.Len()))])
+		if n > 0 {
+			// search slightly before the new data in case the tag spans two reads
+			start := maxInt(0, buf.Len()-len(headEnd))
+			buf.Write(chunk[:n])
+			if !sawHeadEnd && containsHeadEnd(buf.Bytes()[start:]) {
+				sawHeadEnd = true
+				if stopAtHeadEnd {
+					return buf.Bytes(), true, true, nil
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return buf.Bytes(), false, sawHeadEnd, nil
+		}
+		if readErr != nil {
+			return nil, false, sawHeadEnd, readErr
+		}
+	}
+	// the body may end exactly at the limit
+	if n, _ := r.Read(chunk[:1]); n == 0 {
+		return buf.Bytes(), false, sawHeadEnd, nil
+	}
+	return buf.Bytes(), true, sawHeadEnd, nil
+}
+
+const headEnd = "</head>"
+
+// resolver looks up the addresses of a host, see net.Resolver.
+type resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+func containsHeadEnd(b []byte) bool {
+	return bytes.Contains(bytes.ToLower(b), []byte(headEnd))
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.opts.MaxRedirects {
+		return errors.Wrapf(ErrTooManyRedirects, "stopped after %d", f.opts.MaxRedirects)
+	}
+	if err := f.policy.checkURL(req.URL); err != nil {
+		return err
+	}
+	// in enforce mode a redirect may not lead to a disallowed path
+	if checkRobots, _ := req.Context().Value(robotsCheckKey{}).(bool); checkRobots && f.robots.mode == RobotsEnforce {
+		if _, err := f.robots.check(req.Context(), req.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialContext resolves addr itself and only dials addresses allowed by the
+// policy, so a hostname cannot be used to smuggle in an internal address.
+func (f *Fetcher) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid port %q", portStr)
+	}
+	ips, err := f.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, ip := range ips {
+		if err := f.policy.checkIP(ip); err != nil {
+			f.logger.Debugw("refusing to dial", "host", host, "ip", ip.String(), "error", err)
+			lastErr = err
+			continue
+		}
+		conn, err := f.dialer.DialContext(ctx, network, netip.AddrPortFrom(ip.Unmap(), uint16(port)).String())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return conn, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.Errorf("no addresses found for %s", host)
+	}
+	return nil, lastErr
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
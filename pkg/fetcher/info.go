@@ -0,0 +1,71 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// PageInfo describes the response to a page fetch.
+type PageInfo struct {
+	StatusCode   int
+	ETag         string
+	LastModified string
+	CacheControl string
+	FetchedAt    time.Time
+}
+
+type pageInfoKey struct{}
+
+// WithPageInfo returns a context in which the fetcher records the response
+// to the next HTML page fetch into the returned PageInfo.
+func WithPageInfo(ctx context.Context) (context.Context, *PageInfo) {
+	info := &PageInfo{}
+	return context.WithValue(ctx, pageInfoKey{}, info), info
+}
+
+func recordPageInfo(ctx context.Context, statusCode int, header http.Header) {
+	info, ok := ctx.Value(pageInfoKey{}).(*PageInfo)
+	if !ok {
+		return
+	}
+	info.StatusCode = statusCode
+	info.ETag = header.Get("ETag")
+	info.LastModified = header.Get("Last-Modified")
+	info.CacheControl = header.Get("Cache-Control")
+	info.FetchedAt = time.Now()
+}
+
+// validators are the cache validators of a previously fetched page.
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+type validatorsKey struct{}
+
+// WithValidators returns a context in which HTML page fetches are made
+// conditional on etag and lastModified. A page that has not changed fails
+// with KindNotModified instead of being downloaded again.
+func WithValidators(ctx context.Context, etag, lastModified string) context.Context {
+	if etag == "" && lastModified == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, validatorsKey{}, validators{etag: etag, lastModified: lastModified})
+}
+
+// setValidators adds conditional headers to header and reports whether any
+// were set.
+func setValidators(ctx context.Context, header http.Header) bool {
+	v, ok := ctx.Value(validatorsKey{}).(validators)
+	if !ok {
+		return false
+	}
+	if v.etag != "" {
+		header.Set("If-None-Match", v.etag)
+	}
+	if v.lastModified != "" {
+		header.Set("If-Modified-Since", v.lastModified)
+	}
+	return true
+}
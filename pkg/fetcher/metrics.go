@@ -0,0 +1,73 @@
+package fetcher
+
+import (
+	"sync"
+)
+
+// Metrics are the counters of a Fetcher since it was created.
+type Metrics struct {
+	Requests int64
+	// Errors counts failed requests by kind.
+	Errors map[Kind]int64
+	Robots RobotsMetrics
+}
+
+// RobotsMetrics describe the robots.txt cache.
+type RobotsMetrics struct {
+	CacheHits    int64
+	CacheMisses  int64
+	CacheEntries int
+	Disallowed   int64
+}
+
+// metrics collects the counters behind Metrics.
+type metrics struct {
+	mu      sync.Mutex
+	current Metrics
+}
+
+func newMetrics() *metrics {
+	return &metrics{current: Metrics{Errors: make(map[Kind]int64)}}
+}
+
+func (m *metrics) request(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current.Requests++
+	if err != nil {
+		kind := KindUnreachable
+		if fetchErr, ok := AsError(err); ok {
+			kind = fetchErr.Kind
+		}
+		m.current.Errors[kind]++
+	}
+}
+
+func (m *metrics) robotsLookup(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.current.Robots.CacheHits++
+	} else {
+		m.current.Robots.CacheMisses++
+	}
+}
+
+func (m *metrics) robotsDisallowed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current.Robots.Disallowed++
+}
+
+// Metrics returns a snapshot of the counters of f.
+func (f *Fetcher) Metrics() Metrics {
+	f.metrics.mu.Lock()
+	defer f.metrics.mu.Unlock()
+	snapshot := f.metrics.current
+	snapshot.Errors = make(map[Kind]int64, len(f.metrics.current.Errors))
+	for kind, n := range f.metrics.current.Errors {
+		snapshot.Errors[kind] = n
+	}
+	snapshot.Robots.CacheEntries = f.robots.entries()
+	return snapshot
+}
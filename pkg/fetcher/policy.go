@@ -0,0 +1,162 @@
+package fetcher
+
+import (
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBlocked is returned when a destination is rejected by the fetch policy.
+var ErrBlocked = errors.New("destination blocked by policy")
+
+// reservedPrefixes are address ranges that are never reachable from the
+// public internet and are refused unless explicitly allowed. The IPv6
+// transition ranges, NAT64, 6to4 and Teredo, embed an IPv4 address that
+// may be an internal one.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// policy decides which URLs and addresses the fetcher may connect to.
+type policy struct {
+	allowCIDRs []netip.Prefix
+	denyCIDRs  []netip.Prefix
+	allowHosts []string
+	denyHosts  []string
+}
+
+func newPolicy(opts *Options) (*policy, error) {
+	allowCIDRs, err := parsePrefixes(opts.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	denyCIDRs, err := parsePrefixes(opts.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return &policy{
+		allowCIDRs: allowCIDRs,
+		denyCIDRs:  denyCIDRs,
+		allowHosts: normalizeHosts(opts.AllowHosts),
+		denyHosts:  normalizeHosts(opts.DenyHosts),
+	}, nil
+}
+
+// checkURL validates the scheme and hostname of u.
+func (p *policy) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Wrapf(ErrBlocked, "scheme %q is not allowed", u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return errors.Wrap(ErrBlocked, "missing host")
+	}
+	if matchHost(p.denyHosts, host) {
+		return errors.Wrapf(ErrBlocked, "host %q is denied", host)
+	}
+	if len(p.allowHosts) > 0 && !matchHost(p.allowHosts, host) {
+		return errors.Wrapf(ErrBlocked, "host %q is not in the allow list", host)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return p.checkIP(ip)
+	}
+	return nil
+}
+
+// checkIP rejects private, loopback, link-local, multicast and otherwise
+// reserved addresses unless they are covered by an allowed CIDR.
+func (p *policy) checkIP(ip netip.Addr) error {
+	ip = ip.Unmap()
+	if containsAddr(p.denyCIDRs, ip) {
+		return errors.Wrapf(ErrBlocked, "address %s is denied", ip)
+	}
+	if containsAddr(p.allowCIDRs, ip) {
+		return nil
+	}
+	switch {
+	case ip.IsLoopback(), ip.IsPrivate(), ip.IsUnspecified(),
+		ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast(),
+		ip.IsInterfaceLocalMulticast(), ip.IsMulticast():
+		return errors.Wrapf(ErrBlocked, "address %s is not public", ip)
+	case ip == netip.AddrFrom4([4]byte{255, 255, 255, 255}), containsAddr(reservedPrefixes, ip):
+		return errors.Wrapf(ErrBlocked, "address %s is reserved", ip)
+	}
+	return nil
+}
+
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			ip, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid address %q", v)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid CIDR %q", v)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHosts(hosts []string) []string {
+	normalized := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		h = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(h)), ".")
+		if h != "" {
+			normalized = append(normalized, h)
+		}
+	}
+	return normalized
+}
+
+// matchHost reports whether host equals one of the patterns. A pattern
+// starting with a dot matches any subdomain, e.g. ".internal" matches
+// "db.internal".
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, ".") {
+			if strings.HasSuffix(host, pattern) || host == pattern[1:] {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+func TestPolicyCheckIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"192.0.2.10", false},
+		{"198.18.0.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"ff02::1", false},
+		{"2001:db8::1", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b:1::a00:1", false},
+		// 6to4 of 127.0.0.1 and Teredo
+		{"2002:7f00:1::1", false},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", false},
+		// neighbours of the transition ranges stay public
+		{"2001:4860:4860::8888", true},
+		{"2003::1", true},
+		// IPv4-mapped IPv6 addresses are checked as the IPv4 address
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.216.34", true},
+	}
+	p, err := newPolicy(DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		err := p.checkIP(netip.MustParseAddr(tt.ip))
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("checkIP(%s) allowed = %v, want %v (err %v)", tt.ip, allowed, tt.allowed, err)
+		}
+		if err != nil && !errors.Is(err, ErrBlocked) {
+			t.Errorf("checkIP(%s) = %v, want ErrBlocked", tt.ip, err)
+		}
+	}
+}
+
+func TestPolicyLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		url     string
+		allowed bool
+	}{
+		{"public host", Options{}, "https://example.com/", true},
+		{"ftp scheme", Options{}, "ftp://example.com/", false},
+		{"file scheme", Options{}, "file:///etc/passwd", false},
+		{"missing host", Options{}, "http:///path", false},
+		{"literal loopback", Options{}, "http://127.0.0.1:8080/", false},
+		{"literal mapped loopback", Options{}, "http://[::ffff:127.0.0.1]/", false},
+		{"literal metadata address", Options{}, "http://169.254.169.254/latest/", false},
+		{"allowed cidr", Options{AllowCIDRs: []string{"10.1.0.0/16"}}, "http://10.1.2.3/", true},
+		{"outside allowed cidr", Options{AllowCIDRs: []string{"10.1.0.0/16"}}, "http://10.2.0.1/", false},
+		{"allowed single address", Options{AllowCIDRs: []string{"127.0.0.1"}}, "http://127.0.0.1/", true},
+		{"denied cidr", Options{DenyCIDRs: []string{"93.184.216.0/24"}}, "http://93.184.216.34/", false},
+		{"deny beats allow", Options{AllowCIDRs: []string{"10.0.0.0/8"}, DenyCIDRs: []string{"10.0.0.0/24"}}, "http://10.0.0.5/", false},
+		{"denied host", Options{DenyHosts: []string{"evil.example"}}, "http://evil.example/", false},
+		{"denied host case and dot", Options{DenyHosts: []string{"evil.example"}}, "http://EVIL.example./", false},
+		{"denied subdomain", Options{DenyHosts: []string{".internal"}}, "http://db.internal/", false},
+		{"denied suffix domain itself", Options{DenyHosts: []string{".internal"}}, "http://internal/", false},
+		{"deny suffix is not a substring", Options{DenyHosts: []string{".internal"}}, "http://notinternal/", true},
+		{"allowed host", Options{AllowHosts: []string{"example.com"}}, "http://example.com/", true},
+		{"not in allow list", Options{AllowHosts: []string{"example.com"}}, "http://example.org/", false},
+		{"allowed subdomain", Options{AllowHosts: []string{".example.com"}}, "http://www.example.com/", true},
+		{"allow list still checks addresses", Options{AllowHosts: []string{"127.0.0.1"}}, "http://127.0.0.1/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			p, err := newPolicy(&opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.checkURL(u)
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Errorf("checkURL(%s) allowed = %v, want %v (err %v)", tt.url, allowed, tt.allowed, err)
+			}
+		})
+	}
+}
+
+func TestNewPolicyRejectsInvalidCIDRs(t *testing.T) {
+	for _, value := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.0/"} {
+		if _, err := newPolicy(&Options{AllowCIDRs: []string{value}}); err == nil {
+			t.Errorf("newPolicy(AllowCIDRs %q) succeeded, want an error", value)
+		}
+	}
+}
+
+// staticResolver resolves every host from a fixed table.
+type staticResolver map[string][]netip.Addr
+
+func (r staticResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	if ips, ok := r[host]; ok {
+		return ips, nil
+	}
+	return nil, errors.Errorf("no such host %s", host)
+}
+
+// newTestFetcher returns a fetcher resolving names through hosts.
+func newTestFetcher(t *testing.T, opts *Options, hosts staticResolver) *Fetcher {
+	t.Helper()
+	f, err := New(opts, logger.GetInstance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.resolver = hosts
+	return f
+}
+
+func TestFetchChecksAddressesAtDialTime(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = w.Write([]byte("<html><head><title>x</title></head></html>"))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	loopback := netip.MustParseAddr("127.0.0.1")
+	hosts := staticResolver{
+		// a public looking name that resolves to loopback, as with DNS rebinding
+		"rebind.example": {loopback},
+		// the first address is refused, the next allowed one is dialed
+		"mixed.example":  {netip.MustParseAddr("10.0.0.1"), loopback},
+		"mapped.example": {netip.MustParseAddr("::ffff:127.0.0.1")},
+	}
+
+	f := newTestFetcher(t, DefaultOptions(), hosts)
+	for _, host := range []string{"rebind.example", "mapped.example"} {
+		_, err := f.Fetch(context.Background(), "http://"+host+":"+port+"/")
+		fetchErr, ok := AsError(err)
+		if !ok || fetchErr.Kind != KindBlocked {
+			t.Errorf("fetching %s: got %v, want a blocked error", host, err)
+		}
+	}
+	if n := atomic.LoadInt32(&hits); n != 0 {
+		t.Fatalf("server was reached %d times through blocked addresses", n)
+	}
+
+	opts := DefaultOptions()
+	opts.AllowCIDRs = []string{"127.0.0.1/32"}
+	f = newTestFetcher(t, opts, hosts)
+	if _, err := f.Fetch(context.Background(), "http://mixed.example:"+port+"/"); err != nil {
+		t.Fatalf("fetching through an allowed address: %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("server was reached %d times, want 1", n)
+	}
+}
+
+func TestFetchRechecksRedirects(t *testing.T) {
+	var target string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/page" {
+			_, _ = w.Write([]byte("<html><head><title>x</title></head></html>"))
+			return
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	hosts := staticResolver{
+		"public.example":   {netip.MustParseAddr("127.0.0.1")},
+		"internal.example": {netip.MustParseAddr("10.0.0.1")},
+		"denied.example":   {netip.MustParseAddr("127.0.0.1")},
+	}
+	opts := DefaultOptions()
+	opts.AllowCIDRs = []string{"127.0.0.1/32"}
+	opts.DenyHosts = []string{"denied.example"}
+	f := newTestFetcher(t, opts, hosts)
+
+	tests := []struct {
+		target string
+		kind   Kind
+	}{
+		{"http://169.254.169.254/latest/meta-data/", KindBlocked},
+		{"http://[::ffff:10.0.0.1]/", KindBlocked},
+		{"http://internal.example:" + port + "/page", KindBlocked},
+		{"http://denied.example:" + port + "/page", KindBlocked},
+		{"file:///etc/passwd", KindBlocked},
+		{"http://public.example:" + port + "/page", ""},
+		// a redirect loop
+		{"http://public.example:" + port + "/start", KindTooManyRedirects},
+	}
+	for _, tt := range tests {
+		target = tt.target
+		_, err := f.Fetch(context.Background(), "http://public.example:"+port+"/start")
+		if tt.kind == "" {
+			if err != nil {
+				t.Errorf("redirect to %s: %v", tt.target, err)
+			}
+			continue
+		}
+		fetchErr, ok := AsError(err)
+		if !ok || fetchErr.Kind != tt.kind {
+			t.Errorf("redirect to %s: got %v, want %s", tt.target, err, tt.kind)
+		}
+	}
+}
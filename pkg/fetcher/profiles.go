@@ -0,0 +1,186 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownProfile is returned for a header profile that is not defined.
+var ErrUnknownProfile = errors.New("unknown header profile")
+
+// Profile is a named set of request headers. Some sites only serve their
+// tags to known crawlers, others block anything that is not a browser.
+type Profile struct {
+	UserAgent      string            `json:"userAgent"`
+	Accept         string            `json:"accept"`
+	AcceptLanguage string            `json:"acceptLanguage"`
+	Headers        map[string]string `json:"headers"`
+}
+
+// Built-in profile names.
+const (
+	ProfileDefault             = "default"
+	ProfileFacebookExternalHit = "facebookexternalhit"
+	ProfileTwitterbot          = "twitterbot"
+	ProfileBrowser             = "browser"
+)
+
+// DefaultProfiles returns the built-in profiles.
+func DefaultProfiles() map[string]Profile {
+	return map[string]Profile{
+		ProfileDefault: {
+			UserAgent:      "Mozilla/5.0 (compatible; opengraph-thumbnail/1.0; +https://github.com/GDGVIT/opengraph-thumbnail-backend)",
+			Accept:         "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8",
+			AcceptLanguage: "en-US,en;q=0.8",
+		},
+		ProfileFacebookExternalHit: {
+			UserAgent: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+			Accept:    "*/*",
+		},
+		ProfileTwitterbot: {
+			UserAgent: "Twitterbot/1.0",
+			Accept:    "*/*",
+		},
+		ProfileBrowser: {
+			UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Accept:         "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+			AcceptLanguage: "en-US,en;q=0.9",
+			Headers: map[string]string{
+				"Sec-Fetch-Dest":            "document",
+				"Sec-Fetch-Mode":            "navigate",
+				"Sec-Fetch-Site":            "none",
+				"Upgrade-Insecure-Requests": "1",
+			},
+		},
+	}
+}
+
+// domainProfile selects a profile for hosts matching pattern, see matchHost.
+type domainProfile struct {
+	pattern string
+	profile string
+}
+
+// profiles resolves the header profile of each request.
+type profiles struct {
+	byName   map[string]Profile
+	fallback string
+	domains  []domainProfile
+}
+
+func newProfiles(opts *Options) (*profiles, error) {
+	p := &profiles{byName: DefaultProfiles(), fallback: opts.Profile}
+	if opts.ProfilesFile != "" {
+		data, err := os.ReadFile(opts.ProfilesFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading header profiles")
+		}
+		var custom map[string]Profile
+		if err := json.Unmarshal(data, &custom); err != nil {
+			return nil, errors.Wrapf(err, "parsing header profiles %s", opts.ProfilesFile)
+		}
+		for name, profile := range custom {
+			p.byName[name] = profile
+		}
+	}
+	if !p.has(p.fallback) {
+		return nil, errors.Wrapf(ErrUnknownProfile, "%q", p.fallback)
+	}
+	for _, rule := range opts.DomainProfiles {
+		pattern, name, ok := strings.Cut(rule, "=")
+		hosts := normalizeHosts([]string{pattern})
+		if !ok || len(hosts) == 0 {
+			return nil, errors.Errorf("invalid domain profile %q, expected domain=profile", rule)
+		}
+		if !p.has(name) {
+			return nil, errors.Wrapf(ErrUnknownProfile, "%q in %q", name, rule)
+		}
+		p.domains = append(p.domains, domainProfile{pattern: hosts[0], profile: name})
+	}
+	return p, nil
+}
+
+func (p *profiles) has(name string) bool {
+	_, ok := p.byName[name]
+	return ok
+}
+
+// names returns the defined profile names, sorted.
+func (p *profiles) names() []string {
+	names := make([]string, 0, len(p.byName))
+	for name := range p.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve returns the profile for a request to host: the one of the
+// request, then the one of ctx, then the first matching domain rule and
+// finally the configured default.
+func (p *profiles) resolve(ctx context.Context, requested, host string) (string, Profile, error) {
+	name := requested
+	if name == "" {
+		name = ProfileFromContext(ctx)
+	}
+	if name == "" {
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		for _, rule := range p.domains {
+			if matchHost([]string{rule.pattern}, host) {
+				name = rule.profile
+				break
+			}
+		}
+	}
+	if name == "" {
+		name = p.fallback
+	}
+	profile, ok := p.byName[name]
+	if !ok {
+		return "", Profile{}, errors.Wrapf(ErrUnknownProfile, "%q, expected one of %s", name, strings.Join(p.names(), ", "))
+	}
+	return name, profile, nil
+}
+
+// apply sets the headers of profile that the request did not set itself.
+func (profile Profile) apply(header http.Header) {
+	set := func(key, value string) {
+		if value != "" && header.Get(key) == "" {
+			header.Set(key, value)
+		}
+	}
+	set("User-Agent", profile.UserAgent)
+	set("Accept", profile.Accept)
+	set("Accept-Language", profile.AcceptLanguage)
+	for key, value := range profile.Headers {
+		set(key, value)
+	}
+}
+
+type profileKey struct{}
+
+// WithProfile returns a context in which fetches use the header profile
+// called name unless the request names one itself.
+func WithProfile(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, profileKey{}, name)
+}
+
+// ProfileFromContext returns the profile name set with WithProfile, if any.
+func ProfileFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(profileKey{}).(string)
+	return name
+}
+
+// HasProfile reports whether a header profile called name is defined.
+func (f *Fetcher) HasProfile(name string) bool {
+	return f.profiles.has(name)
+}
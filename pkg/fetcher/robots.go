@@ -0,0 +1,157 @@
+package fetcher
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/cache"
+	"github.com/pkg/errors"
+	"github.com/temoto/robotstxt"
+	"golang.org/x/sync/singleflight"
+)
+
+// Robots modes, see Options.Robots.
+const (
+	RobotsOff      = "off"
+	RobotsAnnotate = "annotate"
+	RobotsEnforce  = "enforce"
+)
+
+// RobotsVerdict is what robots.txt says about a fetched URL.
+type RobotsVerdict string
+
+const (
+	RobotsUnchecked  RobotsVerdict = ""
+	RobotsAllowed    RobotsVerdict = "allowed"
+	RobotsDisallowed RobotsVerdict = "disallowed"
+)
+
+// ErrDisallowed is returned in enforce mode for paths robots.txt disallows.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+const robotsMaxBytes = 512 << 10
+
+// robotsTimeout bounds fetching one robots.txt, including the wait for its
+// domain. The fetch is shared by every request to the origin, so it does
+// not use the context of the request that started it.
+const robotsTimeout = 15 * time.Second
+
+// robotsCheckKey marks the context of a fetch whose redirects are checked
+// against robots.txt too.
+type robotsCheckKey struct{}
+
+// robots fetches, caches and evaluates the robots.txt of each origin.
+type robots struct {
+	f         *Fetcher
+	mode      string
+	userAgent string
+	ttl       time.Duration
+	cache     *cache.LRU[*robotstxt.RobotsData]
+	group     singleflight.Group
+}
+
+func newRobots(f *Fetcher, opts *Options) (*robots, error) {
+	switch opts.Robots {
+	case RobotsOff, RobotsAnnotate, RobotsEnforce:
+	default:
+		return nil, errors.Errorf("unknown robots mode %q", opts.Robots)
+	}
+	return &robots{
+		f:         f,
+		mode:      opts.Robots,
+		userAgent: opts.RobotsUserAgent,
+		ttl:       opts.RobotsTTL,
+		cache:     cache.NewLRU[*robotstxt.RobotsData](opts.RobotsCacheSize),
+	}, nil
+}
+
+// check evaluates u against the robots.txt of its origin. In enforce mode
+// a disallowed path is an error.
+func (r *robots) check(ctx context.Context, u *url.URL) (RobotsVerdict, error) {
+	if r.mode == RobotsOff {
+		return RobotsUnchecked, nil
+	}
+	data, err := r.load(ctx, u)
+	if err != nil {
+		return RobotsUnchecked, classify(u.String(), errors.Wrap(err, "waiting for robots.txt"))
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if data.TestAgent(path, r.userAgent) {
+		return RobotsAllowed, nil
+	}
+	r.f.metrics.robotsDisallowed()
+	if r.mode == RobotsEnforce {
+		return RobotsDisallowed, &Error{
+			Kind: KindDisallowed,
+			URL:  u.String(),
+			Err:  errors.Wrapf(ErrDisallowed, "user agent %q may not fetch %s", r.userAgent, path),
+		}
+	}
+	return RobotsDisallowed, nil
+}
+
+// load returns the cached robots.txt of the origin of u, fetching it when
+// missing. Following the usual conventions a missing file allows
+// everything and a server error disallows everything. It only fails when
+// ctx is done before the file is available.
+func (r *robots) load(ctx context.Context, u *url.URL) (*robotstxt.RobotsData, error) {
+	origin := u.Scheme + "://" + u.Host
+	if data, ok := r.cache.Get(origin); ok {
+		r.f.metrics.robotsLookup(true)
+		return data, nil
+	}
+	r.f.metrics.robotsLookup(false)
+
+	ch := r.group.DoChan(origin, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), robotsTimeout)
+		defer cancel()
+		data := r.fetch(ctx, origin)
+		r.cache.Set(origin, data, r.ttl)
+		return data, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ContextError(u.String(), ctx.Err())
+	case res := <-ch:
+		return res.Val.(*robotstxt.RobotsData), nil
+	}
+}
+
+func (r *robots) fetch(ctx context.Context, origin string) *robotstxt.RobotsData {
+	robotsURL := origin + "/robots.txt"
+	res, err := r.f.do(ctx, &Request{URL: robotsURL, MaxBytes: robotsMaxBytes, Partial: true}, false)
+	status, body := 0, []byte(nil)
+	switch fetchErr, _ := AsError(err); {
+	case err == nil:
+		status, body = res.StatusCode, res.Body
+	case fetchErr != nil && fetchErr.Kind == KindUpstreamStatus:
+		status = fetchErr.StatusCode
+	default:
+		// an unreachable robots.txt should not make the page unreachable too
+		r.f.logger.Debugw("robots.txt fetch failed, allowing all", "url", robotsURL, "error", err)
+		return allowAll()
+	}
+	data, err := robotstxt.FromStatusAndBytes(status, body)
+	if err != nil {
+		r.f.logger.Debugw("invalid robots.txt, allowing all", "url", robotsURL, "error", err)
+		return allowAll()
+	}
+	return data
+}
+
+func allowAll() *robotstxt.RobotsData {
+	data, _ := robotstxt.FromStatusAndBytes(404, nil)
+	return data
+}
+
+// entries returns the number of cached robots.txt files.
+func (r *robots) entries() int {
+	return r.cache.Len()
+}
@@ -0,0 +1,149 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/logger"
+)
+
+// robotsServer disallows /private, and redirects /to-private and
+// /to-public to the paths they name. Its
+// robots.txt is answered once release is closed.
+func robotsServer(t *testing.T, release chan struct{}, robotsHits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			atomic.AddInt32(robotsHits, 1)
+			<-release
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/to-private":
+			http.Redirect(w, r, "/private", http.StatusFound)
+		case "/to-public":
+			http.Redirect(w, r, "/public", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("<html><head><title>x</title></head></html>"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newRobotsFetcher(t *testing.T, mode string) *Fetcher {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.AllowCIDRs = []string{"127.0.0.1/32"}
+	opts.Robots = mode
+	f, err := New(opts, logger.GetInstance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestRobotsEnforce(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	var hits int32
+	server := robotsServer(t, release, &hits)
+	f := newRobotsFetcher(t, RobotsEnforce)
+
+	tests := []struct {
+		path string
+		kind Kind
+	}{
+		{"/public", ""},
+		{"/private", KindDisallowed},
+		{"/to-public", ""},
+		{"/to-private", KindDisallowed},
+	}
+	for _, tt := range tests {
+		res, err := f.Fetch(context.Background(), server.URL+tt.path)
+		if tt.kind == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.path, err)
+			} else if res.Robots != RobotsAllowed {
+				t.Errorf("%s: verdict %q, want allowed", tt.path, res.Robots)
+			}
+			continue
+		}
+		if !isKind(err, tt.kind) {
+			t.Errorf("%s: error = %v, want %s", tt.path, err, tt.kind)
+		}
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", hits)
+	}
+}
+
+func TestRobotsAnnotateRedirect(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	var hits int32
+	server := robotsServer(t, release, &hits)
+	f := newRobotsFetcher(t, RobotsAnnotate)
+
+	for path, want := range map[string]RobotsVerdict{
+		"/public":     RobotsAllowed,
+		"/private":    RobotsDisallowed,
+		"/to-private": RobotsDisallowed,
+		"/to-public":  RobotsAllowed,
+	} {
+		res, err := f.Fetch(context.Background(), server.URL+path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if res.Robots != want {
+			t.Errorf("%s: verdict %q, want %q", path, res.Robots, want)
+		}
+	}
+}
+
+func TestRobotsSharedFetchOutlivesCaller(t *testing.T) {
+	release := make(chan struct{})
+	var hits int32
+	server := robotsServer(t, release, &hits)
+	f := newRobotsFetcher(t, RobotsEnforce)
+
+	// the first caller starts the robots.txt fetch and goes away
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := f.Fetch(first, server.URL+"/private")
+		firstErr <- err
+	}()
+	for atomic.LoadInt32(&hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	secondErr := make(chan error)
+	go func() {
+		_, err := f.Fetch(context.Background(), server.URL+"/private")
+		secondErr <- err
+	}()
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller got %v, want context.Canceled", err)
+	}
+	// let the second caller join the shared fetch before it returns
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-secondErr; !isKind(err, KindDisallowed) {
+		t.Errorf("waiting caller got %v, want %s", err, KindDisallowed)
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", hits)
+	}
+}
+
+func isKind(err error, kind Kind) bool {
+	fetchErr, ok := AsError(err)
+	return ok && fetchErr.Kind == kind
+}
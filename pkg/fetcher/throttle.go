@@ -0,0 +1,206 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
+)
+
+// ErrThrottled is returned when a domain stays saturated for longer than
+// the queue timeout.
+var ErrThrottled = errors.New("host throttled")
+
+// idleDomainAge is how long the limits of a domain are kept after its last
+// fetch, once maxIdleDomains domains are tracked.
+const (
+	idleDomainAge  = 10 * time.Minute
+	maxIdleDomains = 10000
+)
+
+// hostLimit is the politeness applied to a registrable domain.
+type hostLimit struct {
+	rate     rate.Limit
+	burst    int
+	inFlight int
+}
+
+// domainState is the limiter and the in-flight slots of one domain.
+type domainState struct {
+	limiter  *rate.Limiter
+	slots    chan struct{}
+	lastUsed time.Time
+}
+
+// throttle keeps a token bucket and a semaphore per registrable domain so a
+// burst of requests for one site does not hammer it.
+type throttle struct {
+	defaults     hostLimit
+	overrides    map[string]hostLimit
+	queueTimeout time.Duration
+
+	mu      sync.Mutex
+	domains map[string]*domainState
+}
+
+func newThrottle(opts *Options) (*throttle, error) {
+	t := &throttle{
+		defaults: hostLimit{
+			rate:     rate.Limit(opts.HostRate),
+			burst:    opts.HostBurst,
+			inFlight: opts.HostMaxInFlight,
+		},
+		overrides:    make(map[string]hostLimit),
+		queueTimeout: opts.HostQueueTimeout,
+		domains:      make(map[string]*domainState),
+	}
+	for _, override := range opts.HostLimits {
+		domain, limit, err := parseHostLimit(override, t.defaults)
+		if err != nil {
+			return nil, err
+		}
+		t.overrides[domain] = limit
+	}
+	return t, nil
+}
+
+// parseHostLimit parses "domain=rate/burst/inflight". Omitted trailing
+// values keep their defaults.
+func parseHostLimit(value string, defaults hostLimit) (string, hostLimit, error) {
+	domain, spec, ok := strings.Cut(value, "=")
+	if !ok || domain == "" {
+		return "", hostLimit{}, errors.Errorf("invalid host limit %q, expected domain=rate/burst/inflight", value)
+	}
+	limit := defaults
+	parts := strings.Split(spec, "/")
+	if len(parts) > 3 {
+		return "", hostLimit{}, errors.Errorf("invalid host limit %q, expected domain=rate/burst/inflight", value)
+	}
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		var err error
+		switch i {
+		case 0:
+			var r float64
+			r, err = strconv.ParseFloat(part, 64)
+			limit.rate = rate.Limit(r)
+		case 1:
+			limit.burst, err = strconv.Atoi(part)
+		case 2:
+			limit.inFlight, err = strconv.Atoi(part)
+		}
+		if err != nil {
+			return "", hostLimit{}, errors.Wrapf(err, "invalid host limit %q", value)
+		}
+	}
+	return registrableDomain(domain), limit, nil
+}
+
+// acquire waits until host may be fetched, for at most the queue timeout
+// or until ctx is done. The returned function releases the in-flight slot.
+// Only running out of the queue timeout fails with ErrThrottled; when ctx
+// ends first its own error is returned, so a caller's deadline is reported
+// as a timeout rather than as throttling.
+func (t *throttle) acquire(ctx context.Context, host string) (func(), error) {
+	domain := registrableDomain(host)
+	state := t.state(domain)
+
+	parent := ctx
+	// whether the deadline of ctx comes before the queue timeout
+	parentFirst := false
+	if deadline, ok := parent.Deadline(); ok {
+		parentFirst = t.queueTimeout <= 0 || deadline.Before(time.Now().Add(t.queueTimeout))
+	}
+	if t.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.queueTimeout)
+		defer cancel()
+	}
+	fail := func(format string) error {
+		switch {
+		case parent.Err() != nil:
+			return errors.Wrapf(parent.Err(), format, domain)
+		case parentFirst:
+			// the limiter gives up early when the wait would pass the deadline
+			return errors.Wrapf(context.DeadlineExceeded, format, domain)
+		}
+		return errors.Wrapf(ErrThrottled, format, domain)
+	}
+
+	release := func() {}
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+			release = func() { <-state.slots }
+		case <-ctx.Done():
+			return nil, fail("%s has too many requests in flight")
+		}
+	}
+	if state.limiter != nil {
+		// Wait fails at once when the next token comes after the deadline
+		if err := state.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, fail("%s is over its request rate")
+		}
+	}
+	return release, nil
+}
+
+// state returns the limits of domain, creating them on first use.
+func (t *throttle) state(domain string) *domainState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	state, ok := t.domains[domain]
+	if !ok {
+		if len(t.domains) >= maxIdleDomains {
+			t.sweep(now)
+		}
+		limit, ok := t.overrides[domain]
+		if !ok {
+			limit = t.defaults
+		}
+		state = &domainState{}
+		if limit.rate > 0 {
+			state.limiter = rate.NewLimiter(limit.rate, maxInt(1, limit.burst))
+		}
+		if limit.inFlight > 0 {
+			state.slots = make(chan struct{}, limit.inFlight)
+		}
+		t.domains[domain] = state
+	}
+	state.lastUsed = now
+	return state
+}
+
+// sweep forgets domains that have been idle for a while. A forgotten
+// domain starts again with a full bucket.
+func (t *throttle) sweep(now time.Time) {
+	for domain, state := range t.domains {
+		if now.Sub(state.lastUsed) > idleDomainAge && (state.slots == nil || len(state.slots) == 0) {
+			delete(t.domains, domain)
+		}
+	}
+}
+
+// registrableDomain returns the domain host belongs to, e.g. example.co.uk
+// for www.example.co.uk. IP addresses and unknown suffixes are kept as is.
+func registrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
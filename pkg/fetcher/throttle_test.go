@@ -0,0 +1,121 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestThrottle(t *testing.T, configure func(*Options)) *throttle {
+	t.Helper()
+	opts := DefaultOptions()
+	configure(opts)
+	th, err := newThrottle(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return th
+}
+
+func TestThrottleInFlight(t *testing.T) {
+	th := newTestThrottle(t, func(o *Options) {
+		o.HostRate = 0
+		o.HostMaxInFlight = 1
+		o.HostQueueTimeout = 20 * time.Millisecond
+	})
+	release, err := th.acquire(context.Background(), "www.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another host of the same domain shares the slot
+	_, err = th.acquire(context.Background(), "cdn.example.com")
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("queue timeout: error = %v, want ErrThrottled", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = th.acquire(ctx, "example.com")
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrThrottled) {
+		t.Errorf("canceled caller: error = %v, want context.Canceled", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = th.acquire(ctx, "example.com")
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrThrottled) {
+		t.Errorf("caller deadline: error = %v, want context.DeadlineExceeded", err)
+	}
+	if kind := classify("https://example.com/", err).Kind; kind != KindTimeout {
+		t.Errorf("caller deadline classified as %s, want %s", kind, KindTimeout)
+	}
+
+	release()
+	release, err = th.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("released slot: %v", err)
+	}
+	release()
+}
+
+func TestThrottleRate(t *testing.T) {
+	th := newTestThrottle(t, func(o *Options) {
+		o.HostRate = 0.1
+		o.HostBurst = 1
+		o.HostMaxInFlight = 0
+		o.HostQueueTimeout = 20 * time.Millisecond
+	})
+	release, err := th.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// the next token is seconds away, past the queue timeout
+	_, err = th.acquire(context.Background(), "example.com")
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("queue timeout: error = %v, want ErrThrottled", err)
+	}
+	// and a caller deadline shorter than it
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = th.acquire(ctx, "example.com")
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrThrottled) {
+		t.Errorf("caller deadline: error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// other domains have their own bucket
+	if _, err := th.acquire(context.Background(), "example.org"); err != nil {
+		t.Errorf("other domain: %v", err)
+	}
+}
+
+func TestParseHostLimit(t *testing.T) {
+	defaults := hostLimit{rate: 1, burst: 2, inFlight: 3}
+	tests := []struct {
+		value  string
+		domain string
+		want   hostLimit
+		ok     bool
+	}{
+		{"www.example.co.uk=5/10/4", "example.co.uk", hostLimit{rate: 5, burst: 10, inFlight: 4}, true},
+		{"example.com=0.5", "example.com", hostLimit{rate: 0.5, burst: 2, inFlight: 3}, true},
+		{"example.com=//8", "example.com", hostLimit{rate: 1, burst: 2, inFlight: 8}, true},
+		{"example.com", "", hostLimit{}, false},
+		{"=1/2/3", "", hostLimit{}, false},
+		{"example.com=1/2/3/4", "", hostLimit{}, false},
+		{"example.com=fast", "", hostLimit{}, false},
+	}
+	for _, tt := range tests {
+		domain, got, err := parseHostLimit(tt.value, defaults)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseHostLimit(%q) error = %v", tt.value, err)
+			continue
+		}
+		if domain != tt.domain || got != tt.want {
+			t.Errorf("parseHostLimit(%q) = %q, %+v, want %q, %+v", tt.value, domain, got, tt.domain, tt.want)
+		}
+	}
+}
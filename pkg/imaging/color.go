@@ -0,0 +1,36 @@
+package imaging
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseColor parses a hex colour written as rgb, rrggbb or rrggbbaa, with
+// or without a leading #.
+func ParseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.RGBA{}, errors.Errorf("invalid colour %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, errors.Errorf("invalid colour %q", s)
+	}
+	// color.RGBA is alpha-premultiplied
+	r, g, b, a := uint8(v>>24), uint8(v>>16), uint8(v>>8), uint8(v)
+	return color.RGBA{
+		R: uint8(uint16(r) * uint16(a) / 255),
+		G: uint8(uint16(g) * uint16(a) / 255),
+		B: uint8(uint16(b) * uint16(a) / 255),
+		A: a,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package imaging
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.RGBA
+		ok   bool
+	}{
+		{"#fff", color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, true},
+		{"0a1", color.RGBA{R: 0x00, G: 0xaa, B: 0x11, A: 0xff}, true},
+		{"#2563EB", color.RGBA{R: 0x25, G: 0x63, B: 0xeb, A: 0xff}, true},
+		{" 112233 ", color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}, true},
+		// alpha is premultiplied
+		{"#ff000080", color.RGBA{R: 0x80, A: 0x80}, true},
+		{"#ffffff00", color.RGBA{}, true},
+		{"", color.RGBA{}, false},
+		{"#ff", color.RGBA{}, false},
+		{"#fffff", color.RGBA{}, false},
+		{"#ggg", color.RGBA{}, false},
+		{"#+12345", color.RGBA{}, false},
+		{"blue", color.RGBA{}, false},
+		{"##fff", color.RGBA{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseColor(%q) err = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
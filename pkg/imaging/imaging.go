@@ -0,0 +1,152 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+
+	// decoders for formats that are read but never written
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// Output formats.
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatGIF  = "gif"
+)
+
+// Decode decodes an image in any supported format and returns the name of
+// the format.
+func Decode(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "decoding image")
+	}
+	return img, format, nil
+}
+
+// DecodeConfig returns the dimensions and format of an image without
+// decoding it, so oversized images can be rejected cheaply.
+func DecodeConfig(data []byte) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, "", errors.Wrap(err, "decoding image header")
+	}
+	return config, format, nil
+}
+
+// Encode writes img in format, quality applies to JPEG only.
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	var err error
+	switch format {
+	case FormatPNG:
+		err = png.Encode(w, img)
+	case FormatJPEG:
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatGIF:
+		err = gif.Encode(w, img, nil)
+	default:
+		return errors.Errorf("unsupported image format %q", format)
+	}
+	return errors.Wrapf(err, "encoding %s", format)
+}
+
+// ContentType returns the media type of an output format.
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Cover scales src to fill a width×height image, cropping the overflow
+// around the centre.
+func Cover(src image.Image, width, height int) *image.RGBA {
+	b := src.Bounds()
+	if b.Empty() {
+		return image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	scale := maxFloat(float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy()))
+	// the part of src that ends up visible
+	cropW, cropH := maxInt(1, int(float64(width)/scale)), maxInt(1, int(float64(height)/scale))
+	x := b.Min.X + (b.Dx()-cropW)/2
+	y := b.Min.Y + (b.Dy()-cropH)/2
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, image.Rect(x, y, x+cropW, y+cropH), draw.Src, nil)
+	return dst
+}
+
+// Contain scales src to fit within width×height keeping its aspect ratio.
+// The result is only as large as the scaled image.
+func Contain(src image.Image, width, height int) *image.RGBA {
+	b := src.Bounds()
+	if b.Empty() {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+	scale := minFloat(float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy()))
+	w, h := maxInt(1, int(float64(b.Dx())*scale+0.5)), maxInt(1, int(float64(b.Dy())*scale+0.5))
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// Crop cuts a width×height region out of the centre of src without
+// scaling it. The region is clipped to src.
+func Crop(src image.Image, width, height int) *image.RGBA {
+	b := src.Bounds()
+	w, h := minInt(width, b.Dx()), minInt(height, b.Dy())
+	x := b.Min.X + (b.Dx()-w)/2
+	y := b.Min.Y + (b.Dy()-h)/2
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), src, image.Point{X: x, Y: y}, draw.Src)
+	return dst
+}
+
+// Fill scales src to exactly width×height, ignoring its aspect ratio.
+func Fill(src image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// Flatten draws img over background, for formats without transparency.
+func Flatten(img image.Image, background color.Color) *image.RGBA {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
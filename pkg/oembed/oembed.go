@@ -0,0 +1,61 @@
+package oembed
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Response is an oEmbed JSON response.
+type Response struct {
+	Type            string    `json:"type"`
+	Version         string    `json:"version"`
+	Title           string    `json:"title"`
+	AuthorName      string    `json:"author_name"`
+	AuthorURL       string    `json:"author_url"`
+	ProviderName    string    `json:"provider_name"`
+	ProviderURL     string    `json:"provider_url"`
+	CacheAge        Dimension `json:"cache_age"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	ThumbnailWidth  Dimension `json:"thumbnail_width"`
+	ThumbnailHeight Dimension `json:"thumbnail_height"`
+	// URL is the image source of photo responses.
+	URL    string    `json:"url"`
+	HTML   string    `json:"html"`
+	Width  Dimension `json:"width"`
+	Height Dimension `json:"height"`
+}
+
+// Dimension is an integer that providers sometimes send as a string.
+type Dimension int
+
+func (d *Dimension) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		f, _ := n.Float64()
+		*d = Dimension(f)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// null and other shapes are treated as unknown
+		*d = 0
+		return nil
+	}
+	n64, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "px"), 64)
+	if err != nil {
+		*d = 0
+		return nil
+	}
+	*d = Dimension(n64)
+	return nil
+}
+
+// Parse decodes an oEmbed JSON response.
+func Parse(data []byte) (*Response, error) {
+	var res Response
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
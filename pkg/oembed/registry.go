@@ -0,0 +1,140 @@
+package oembed
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultProviders is the built-in registry, in the providers.json format
+// published at https://oembed.com/providers.json.
+//
+//go:embed providers.json
+var defaultProviders []byte
+
+// Provider is an oEmbed provider as listed in providers.json.
+type Provider struct {
+	Name      string     `json:"provider_name"`
+	URL       string     `json:"provider_url"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Endpoint is an oEmbed API endpoint and the URL schemes it serves.
+type Endpoint struct {
+	Schemes []string `json:"schemes"`
+	URL     string   `json:"url"`
+}
+
+// Registry maps page URLs to the oEmbed endpoint that describes them.
+type Registry struct {
+	routes []route
+}
+
+type route struct {
+	pattern  *regexp.Regexp
+	endpoint string
+}
+
+// NewRegistry - constructor for Registry. Providers are matched in order,
+// so earlier providers win when schemes overlap.
+func NewRegistry(providers []Provider) *Registry {
+	r := &Registry{}
+	for _, p := range providers {
+		for _, e := range p.Endpoints {
+			if e.URL == "" {
+				continue
+			}
+			endpoint := strings.ReplaceAll(e.URL, "{format}", "json")
+			for _, scheme := range e.Schemes {
+				r.routes = append(r.routes, route{pattern: schemePattern(scheme), endpoint: endpoint})
+			}
+		}
+	}
+	return r
+}
+
+// DefaultRegistry returns the built-in registry.
+func DefaultRegistry() *Registry {
+	providers, err := parseProviders(defaultProviders)
+	if err != nil {
+		panic(err)
+	}
+	return NewRegistry(providers)
+}
+
+// LoadRegistry reads providers from a providers.json file. They take
+// precedence over the built-in providers.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading oembed providers")
+	}
+	providers, err := parseProviders(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing oembed providers %s", path)
+	}
+	builtin, err := parseProviders(defaultProviders)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(append(providers, builtin...)), nil
+}
+
+func parseProviders(data []byte) ([]Provider, error) {
+	var providers []Provider
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+// Lookup returns the endpoint for pageURL, if a provider serves it.
+func (r *Registry) Lookup(pageURL string) (string, bool) {
+	for _, rt := range r.routes {
+		if rt.pattern.MatchString(pageURL) {
+			return rt.endpoint, true
+		}
+	}
+	return "", false
+}
+
+// RequestURL builds the JSON request for pageURL against endpoint.
+func RequestURL(endpoint, pageURL string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid oembed endpoint")
+	}
+	query := u.Query()
+	query.Set("url", pageURL)
+	query.Set("format", "json")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// schemePattern compiles an oEmbed scheme such as
+// "https://*.youtube.com/watch*". A * in the host matches within the host
+// only, one in the path matches anything. Schemes for http and https match
+// either protocol.
+func schemePattern(scheme string) *regexp.Regexp {
+	rest := scheme
+	prefix := ""
+	for _, p := range []string{"https://", "http://"} {
+		if strings.HasPrefix(scheme, p) {
+			rest = strings.TrimPrefix(scheme, p)
+			prefix = "https?://"
+			break
+		}
+	}
+	host, path := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		host, path = rest[:i], rest[i:]
+	}
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(host), `\*`, `[^/?#]*`) +
+		strings.ReplaceAll(regexp.QuoteMeta(path), `\*`, ".*")
+	return regexp.MustCompile("^" + prefix + quoted + "$")
+}
@@ -0,0 +1,107 @@
+package oembed
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	r := NewRegistry([]Provider{
+		{Name: "Video", Endpoints: []Endpoint{{
+			Schemes: []string{"https://*.video.example/watch*", "https://video.example/v/*"},
+			URL:     "https://video.example/oembed.{format}",
+		}}},
+		{Name: "Any host", Endpoints: []Endpoint{{
+			Schemes: []string{"http://*/photos/*"},
+			URL:     "https://photos.example/oembed",
+		}}},
+		{Name: "Shadowed", Endpoints: []Endpoint{{
+			Schemes: []string{"https://video.example/v/*"},
+			URL:     "https://shadowed.example/oembed",
+		}}},
+		{Name: "No endpoint", Endpoints: []Endpoint{{Schemes: []string{"https://*"}}}},
+	})
+	tests := []struct {
+		pageURL  string
+		endpoint string
+	}{
+		{"https://www.video.example/watch?v=1", "https://video.example/oembed.json"},
+		{"http://m.video.example/watch", "https://video.example/oembed.json"},
+		// earlier providers win
+		{"https://video.example/v/1", "https://video.example/oembed.json"},
+		{"https://any.example/photos/1", "https://photos.example/oembed"},
+		// a host wildcard stays within the host
+		{"https://evil.example/x.video.example/watch", ""},
+		{"https://evil.example?.video.example/watch", ""},
+		{"https://evil.example#.video.example/watch", ""},
+		{"https://www.video.example.evil.example/watch", ""},
+		{"https://video.example/other", ""},
+		{"ftp://www.video.example/watch", ""},
+	}
+	for _, tt := range tests {
+		endpoint, ok := r.Lookup(tt.pageURL)
+		if endpoint != tt.endpoint || ok != (tt.endpoint != "") {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.pageURL, endpoint, ok, tt.endpoint)
+		}
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	r := DefaultRegistry()
+	if endpoint, ok := r.Lookup("https://www.youtube.com/watch?v=dQw4w9WgXcQ"); !ok || endpoint != "https://www.youtube.com/oembed" {
+		t.Errorf("YouTube endpoint = %q, %v", endpoint, ok)
+	}
+	if _, ok := r.Lookup("https://youtube.com.evil.example/watch?v=1"); ok {
+		t.Error("lookalike host matched")
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"https://video.example/oembed", "https://video.example/oembed?format=json&url=https%3A%2F%2Fvideo.example%2Fv%2F1%3Fa%3Db"},
+		// the endpoint's own query is kept, format is forced
+		{"https://video.example/oembed?maxwidth=600&format=xml", "https://video.example/oembed?format=json&maxwidth=600&url=https%3A%2F%2Fvideo.example%2Fv%2F1%3Fa%3Db"},
+	}
+	for _, tt := range tests {
+		got, err := RequestURL(tt.endpoint, "https://video.example/v/1?a=b")
+		if err != nil || got != tt.want {
+			t.Errorf("RequestURL(%q) = %q, %v, want %q", tt.endpoint, got, err, tt.want)
+		}
+	}
+	if _, err := RequestURL("http://[bad", "https://video.example/"); err == nil {
+		t.Error("invalid endpoint accepted")
+	}
+}
+
+func TestDimensionUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want Dimension
+	}{
+		{`640`, 640},
+		{`640.7`, 640},
+		{`"480"`, 480},
+		{`" 360px "`, 360},
+		{`"auto"`, 0},
+		{`null`, 0},
+		{`true`, 0},
+		{`{}`, 0},
+	}
+	for _, tt := range tests {
+		d := Dimension(1)
+		if err := json.Unmarshal([]byte(tt.json), &d); err != nil || d != tt.want {
+			t.Errorf("unmarshal %s = %d, %v, want %d", tt.json, d, err, tt.want)
+		}
+	}
+
+	var res Response
+	if err := json.Unmarshal([]byte(`{"type":"video","width":"100%","height":"360","thumbnail_width":480}`), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Width != 0 || res.Height != 360 || res.ThumbnailWidth != 480 {
+		t.Errorf("dimensions = %d×%d, thumbnail width %d", res.Width, res.Height, res.ThumbnailWidth)
+	}
+}
@@ -0,0 +1,366 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GDGVIT/opengraph-thumbnail-backend/pkg/imaging"
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Size of a card, the size social networks expect for og:image.
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// Layout arranges the parts of a card.
+type Layout string
+
+const (
+	// LayoutAuto picks LayoutStandard for cards with an image and
+	// LayoutText otherwise.
+	LayoutAuto Layout = ""
+	// LayoutStandard puts the text on the left and the image on the right.
+	LayoutStandard Layout = "standard"
+	// LayoutHero covers the card with the image and fades it into the
+	// background colour behind the text at the bottom.
+	LayoutHero Layout = "hero"
+	// LayoutText shows only the text and a large icon.
+	LayoutText Layout = "text"
+)
+
+// Style is the look of a card.
+type Style struct {
+	Layout          Layout
+	Background      color.RGBA
+	Foreground      color.RGBA
+	Accent          color.RGBA
+	TitleSize       float64
+	DescriptionSize float64
+}
+
+// DefaultStyle returns a light style with a blue accent.
+func DefaultStyle() Style {
+	return Style{
+		Layout:          LayoutAuto,
+		Background:      color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Foreground:      color.RGBA{R: 0x11, G: 0x18, B: 0x27, A: 0xff},
+		Accent:          color.RGBA{R: 0x25, G: 0x63, B: 0xeb, A: 0xff},
+		TitleSize:       64,
+		DescriptionSize: 32,
+	}
+}
+
+// Card is the content of a card. Every part is optional.
+type Card struct {
+	Title       string
+	Description string
+	SiteName    string
+	Image       image.Image
+	Icon        image.Image
+}
+
+const (
+	padding     = 72
+	accentBar   = 12
+	lineSpacing = 1.25
+	siteSize    = 28
+)
+
+var (
+	regularFont = mustParse(goregular.TTF)
+	boldFont    = mustParse(gobold.TTF)
+)
+
+func mustParse(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Render draws card in style.
+func Render(card Card, style Style) (*image.RGBA, error) {
+	layout := style.Layout
+	if layout == LayoutAuto {
+		layout = LayoutText
+		if card.Image != nil {
+			layout = LayoutStandard
+		}
+	}
+	if style.TitleSize <= 0 || style.DescriptionSize <= 0 {
+		return nil, errors.New("font sizes must be positive")
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(style.Background), image.Point{}, draw.Src)
+
+	r := &renderer{dst: dst, style: style}
+	switch layout {
+	case LayoutStandard:
+		r.standard(card)
+	case LayoutHero:
+		r.hero(card)
+	case LayoutText:
+		r.text(card)
+	default:
+		return nil, errors.Errorf("unknown layout %q", layout)
+	}
+	// the accent bar closes every layout
+	draw.Draw(dst, image.Rect(0, Height-accentBar, Width, Height), image.NewUniform(style.Accent), image.Point{}, draw.Src)
+	return dst, nil
+}
+
+type renderer struct {
+	dst   *image.RGBA
+	style Style
+}
+
+// standard draws the text column on the left and the image on the right.
+func (r *renderer) standard(card Card) {
+	textRight := Width - padding
+	if card.Image != nil {
+		imageLeft := Width * 3 / 5
+		cover := imaging.Cover(card.Image, Width-imageLeft, Height)
+		draw.Draw(r.dst, image.Rect(imageLeft, 0, Width, Height), cover, image.Point{}, draw.Src)
+		textRight = imageLeft - padding
+	}
+	y := r.siteRow(card, padding, padding, textRight, 48)
+	r.textBlock(card, padding, y+24, textRight, Height-padding-accentBar, false)
+}
+
+// hero covers the card with the image and fades it into the background
+// behind the text.
+func (r *renderer) hero(card Card) {
+	if card.Image != nil {
+		draw.Draw(r.dst, r.dst.Bounds(), imaging.Cover(card.Image, Width, Height), image.Point{}, draw.Src)
+	}
+	// fade from transparent at the top to nearly opaque at the bottom
+	fadeTop := Height / 4
+	for y := fadeTop; y < Height; y++ {
+		alpha := uint8(235 * (y - fadeTop) / (Height - fadeTop))
+		line := image.NewUniform(withAlpha(r.style.Background, alpha))
+		draw.Draw(r.dst, image.Rect(0, y, Width, y+1), line, image.Point{}, draw.Over)
+	}
+	r.siteRow(card, padding, padding, Width-padding, 48)
+	r.textBlock(card, padding, Height/2, Width-padding, Height-padding-accentBar, true)
+}
+
+// text draws a large icon above the text.
+func (r *renderer) text(card Card) {
+	y := r.siteRow(card, padding, padding, Width-padding, 96)
+	r.textBlock(card, padding, y+32, Width-padding, Height-padding-accentBar, false)
+}
+
+// siteRow draws the icon and site name at (x, y) and returns the bottom of
+// the row.
+func (r *renderer) siteRow(card Card, x, y, right, iconSize int) int {
+	bottom := y
+	textX := x
+	if card.Icon != nil {
+		icon := imaging.Contain(card.Icon, iconSize, iconSize)
+		b := icon.Bounds()
+		draw.Draw(r.dst, image.Rect(x, y, x+b.Dx(), y+b.Dy()), icon, image.Point{}, draw.Over)
+		textX = x + iconSize + 20
+		bottom = y + iconSize
+	}
+	if card.SiteName != "" {
+		face := newFace(boldFont, siteSize)
+		defer face.Close()
+		metrics := face.Metrics()
+		height := (metrics.Ascent + metrics.Descent).Ceil()
+		rowHeight := maxInt(height, bottom-y)
+		baseline := y + (rowHeight-height)/2 + metrics.Ascent.Ceil()
+		lines := wrap(face, card.SiteName, right-textX, 1)
+		r.drawText(face, lines[0], textX, baseline, r.style.Accent)
+		bottom = maxInt(bottom, y+rowHeight)
+	}
+	return bottom
+}
+
+// textBlock draws the title and description between top and bottom. When
+// fromBottom is set the block is aligned to the bottom edge.
+func (r *renderer) textBlock(card Card, left, top, right, bottom int, fromBottom bool) {
+	width := right - left
+	if width <= 0 || bottom <= top {
+		return
+	}
+	titleFace := newFace(boldFont, r.style.TitleSize)
+	defer titleFace.Close()
+	descFace := newFace(regularFont, r.style.DescriptionSize)
+	defer descFace.Close()
+
+	titleStep := int(r.style.TitleSize * lineSpacing)
+	descStep := int(r.style.DescriptionSize * lineSpacing)
+	available := bottom - top
+
+	var titleLines, descLines []string
+	if card.Title != "" {
+		titleLines = wrap(titleFace, card.Title, width, clampLines(available, titleStep, 3))
+		available -= len(titleLines) * titleStep
+	}
+	if card.Description != "" && available > descStep {
+		// keep some space between the title and the description
+		if len(titleLines) > 0 {
+			available -= descStep / 2
+		}
+		descLines = wrap(descFace, card.Description, width, clampLines(available, descStep, 4))
+	}
+
+	height := len(titleLines) * titleStep
+	if len(descLines) > 0 {
+		if len(titleLines) > 0 {
+			height += descStep / 2
+		}
+		height += len(descLines) * descStep
+	}
+	y := top
+	if fromBottom {
+		y = bottom - height
+	}
+
+	for _, line := range titleLines {
+		r.drawText(titleFace, line, left, y+titleFace.Metrics().Ascent.Ceil(), r.style.Foreground)
+		y += titleStep
+	}
+	if len(titleLines) > 0 {
+		y += descStep / 2
+	}
+	muted := mix(r.style.Foreground, r.style.Background, 0.75)
+	for _, line := range descLines {
+		r.drawText(descFace, line, left, y+descFace.Metrics().Ascent.Ceil(), muted)
+		y += descStep
+	}
+}
+
+func (r *renderer) drawText(face font.Face, text string, x, baseline int, c color.Color) {
+	d := &font.Drawer{
+		Dst:  r.dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, baseline),
+	}
+	d.DrawString(text)
+}
+
+func newFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		// only invalid sizes fail, and those are rejected by Render
+		panic(err)
+	}
+	return face
+}
+
+// wrap breaks text into at most maxLines lines no wider than width,
+// ending the last one with an ellipsis if text does not fit.
+func wrap(face font.Face, text string, width, maxLines int) []string {
+	if maxLines < 1 {
+		return nil
+	}
+	limit := fixed.I(width)
+	var lines []string
+	var line string
+	words := strings.Fields(text)
+	for len(words) > 0 {
+		candidate := words[0]
+		if line != "" {
+			candidate = line + " " + words[0]
+		}
+		if font.MeasureString(face, candidate) <= limit {
+			line = candidate
+			words = words[1:]
+			continue
+		}
+		if line == "" {
+			// a single word wider than the line is split
+			head, tail := splitWord(face, words[0], limit)
+			line = head
+			if tail == "" {
+				words = words[1:]
+			} else {
+				words[0] = tail
+			}
+		}
+		lines = append(lines, line)
+		line = ""
+		if len(lines) == maxLines {
+			if len(words) > 0 {
+				lines[maxLines-1] = ellipsis(face, lines[maxLines-1], limit)
+			}
+			return lines
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitWord returns the longest prefix of word that fits in limit, at
+// least one rune, and the rest.
+func splitWord(face font.Face, word string, limit fixed.Int26_6) (string, string) {
+	end := 0
+	for i, r := range word {
+		next := i + utf8.RuneLen(r)
+		if end > 0 && font.MeasureString(face, word[:next]) > limit {
+			break
+		}
+		end = next
+	}
+	return word[:end], word[end:]
+}
+
+// ellipsis shortens line until it fits in limit with a trailing ellipsis.
+func ellipsis(face font.Face, line string, limit fixed.Int26_6) string {
+	for line != "" {
+		candidate := strings.TrimRight(line, " ,.;:") + "…"
+		if font.MeasureString(face, candidate) <= limit {
+			return candidate
+		}
+		_, size := utf8.DecodeLastRuneInString(line)
+		line = line[:len(line)-size]
+	}
+	return "…"
+}
+
+func clampLines(available, step, max int) int {
+	if step <= 0 {
+		return 0
+	}
+	if n := available / step; n < max {
+		return n
+	}
+	return max
+}
+
+// mix blends a into b, weight is the share of a.
+func mix(a, b color.RGBA, weight float64) color.RGBA {
+	blend := func(x, y uint8) uint8 {
+		return uint8(float64(x)*weight + float64(y)*(1-weight))
+	}
+	return color.RGBA{R: blend(a.R, b.R), G: blend(a.G, b.G), B: blend(a.B, b.B), A: blend(a.A, b.A)}
+}
+
+// withAlpha returns the opaque colour c at alpha, premultiplied.
+func withAlpha(c color.RGBA, alpha uint8) color.RGBA {
+	scale := func(v uint8) uint8 { return uint8(uint16(v) * uint16(alpha) / 255) }
+	return color.RGBA{R: scale(c.R), G: scale(c.G), B: scale(c.B), A: alpha}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
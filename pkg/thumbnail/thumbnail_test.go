@@ -0,0 +1,142 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestRenderLayouts(t *testing.T) {
+	photo := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for i := range photo.Pix {
+		photo.Pix[i] = 0x80
+	}
+	card := Card{
+		Title:       strings.Repeat("A rather long title that needs wrapping ", 6),
+		Description: strings.Repeat("Some description text. ", 30),
+		SiteName:    "example.com",
+		Image:       photo,
+		Icon:        image.NewRGBA(image.Rect(0, 0, 16, 16)),
+	}
+	style := DefaultStyle()
+	for _, layout := range []Layout{LayoutAuto, LayoutStandard, LayoutHero, LayoutText} {
+		style.Layout = layout
+		img, err := Render(card, style)
+		if err != nil {
+			t.Fatalf("layout %q: %v", layout, err)
+		}
+		if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+			t.Errorf("layout %q: card is %v", layout, b)
+		}
+		if got := img.RGBAAt(Width/2, Height-1); got != style.Accent {
+			t.Errorf("layout %q: accent bar is %v, want %v", layout, got, style.Accent)
+		}
+	}
+
+	// the text layout leaves the right edge to the background
+	style.Layout = LayoutText
+	img, err := Render(Card{Title: "Title"}, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.RGBAAt(Width-1, Height/2); got != style.Background {
+		t.Errorf("background is %v, want %v", got, style.Background)
+	}
+	// the standard layout puts the image on the right
+	style.Layout = LayoutStandard
+	img, err = Render(card, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.RGBAAt(Width-1, Height/2); got != (color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0x80}) {
+		t.Errorf("image pixel is %v", got)
+	}
+}
+
+func TestRenderRejectsInvalidStyles(t *testing.T) {
+	for _, style := range []Style{
+		{Layout: LayoutText, TitleSize: 0, DescriptionSize: 32},
+		{Layout: LayoutText, TitleSize: 64, DescriptionSize: -1},
+		{Layout: "diagonal", TitleSize: 64, DescriptionSize: 32},
+	} {
+		if _, err := Render(Card{Title: "x"}, style); err == nil {
+			t.Errorf("Render with %+v succeeded", style)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	face := newFace(regularFont, 32)
+	defer face.Close()
+	const width = 400
+	limit := fixed.I(width)
+
+	if lines := wrap(face, "", width, 3); len(lines) != 0 {
+		t.Errorf("empty text wrapped to %q", lines)
+	}
+	if lines := wrap(face, "short", width, 0); lines != nil {
+		t.Errorf("zero lines wrapped to %q", lines)
+	}
+	if lines := wrap(face, "  short  text ", width, 3); len(lines) != 1 || lines[0] != "short text" {
+		t.Errorf("short text wrapped to %q", lines)
+	}
+
+	text := strings.Repeat("word ", 40)
+	lines := wrap(face, text, width, 3)
+	if len(lines) != 3 {
+		t.Fatalf("wrapped to %d lines, want 3: %q", len(lines), lines)
+	}
+	for _, line := range lines {
+		if font.MeasureString(face, line) > limit {
+			t.Errorf("line %q is wider than %d", line, width)
+		}
+	}
+	if !strings.HasSuffix(lines[2], "…") {
+		t.Errorf("last line %q has no ellipsis", lines[2])
+	}
+	if strings.HasSuffix(lines[0], "…") {
+		t.Errorf("first line %q has an ellipsis", lines[0])
+	}
+
+	// text that fits exactly has no ellipsis
+	lines = wrap(face, strings.Repeat("word ", 4), width, 3)
+	if len(lines) != 1 || strings.Contains(lines[0], "…") {
+		t.Errorf("fitting text wrapped to %q", lines)
+	}
+
+	// a word wider than the line is split
+	long := strings.Repeat("x", 100)
+	lines = wrap(face, long, width, 5)
+	if strings.Join(lines, "") != long {
+		t.Errorf("long word wrapped to %q", lines)
+	}
+	for _, line := range lines {
+		if font.MeasureString(face, line) > limit {
+			t.Errorf("split line %q is wider than %d", line, width)
+		}
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	face := newFace(regularFont, 32)
+	defer face.Close()
+
+	if got := ellipsis(face, "Hello, world.", fixed.I(1000)); got != "Hello, world…" {
+		t.Errorf("ellipsis = %q", got)
+	}
+	got := ellipsis(face, "Hello, world", fixed.I(100))
+	if !strings.HasSuffix(got, "…") || font.MeasureString(face, got) > fixed.I(100) {
+		t.Errorf("ellipsis = %q, wider than the limit or missing the ellipsis", got)
+	}
+	if got := ellipsis(face, "héllo wörld", fixed.I(1)); got != "…" {
+		t.Errorf("ellipsis in no space = %q", got)
+	}
+	if got := ellipsis(face, "日本語のテキスト", fixed.I(60)); !strings.HasSuffix(got, "…") || !utf8.ValidString(got) {
+		t.Errorf("ellipsis of multibyte text = %q", got)
+	}
+}